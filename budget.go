@@ -0,0 +1,22 @@
+package claudecode
+
+import "github.com/musaprg/claude-code-sdk-go/internal/budget"
+
+// Re-export usage and pricing types from internal package.
+// They are used to estimate the cost of a query as its messages arrive.
+type (
+	// ModelPricing contains model prices in USD per million tokens.
+	ModelPricing = budget.Pricing
+	// Budget is a spending limit shared by many queries, such as all queries of a tenant or job.
+	Budget = budget.Budget
+	// BudgetLimits caps the cost and tokens of a Budget. Zero values mean no limit.
+	BudgetLimits = budget.Limits
+)
+
+var (
+	// NewBudget creates a Budget with the given limits.
+	NewBudget = budget.NewBudget
+	// PricingFor returns the estimated pricing of a model. Unknown models are priced
+	// like the most expensive model so that budget limits err on the safe side.
+	PricingFor = budget.PricingFor
+)
@@ -0,0 +1,121 @@
+package claudecode
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSpendingCLI writes a fake CLI that reports two API messages of 1000 output tokens
+// each and then hangs, as if the agent kept working.
+func writeSpendingCLI(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fake-claude")
+	script := `#!/bin/sh
+if [ "$1" = "--version" ]; then echo "2.0.0 (Claude Code)"; exit 0; fi
+echo '{"type":"system","subtype":"init","session_id":"s1"}'
+echo '{"type":"assistant","message":{"id":"msg_1","model":"claude-sonnet-4-5","content":[{"type":"text","text":"a"}],"usage":{"input_tokens":10,"output_tokens":1000}}}'
+echo '{"type":"assistant","message":{"id":"msg_2","model":"claude-sonnet-4-5","content":[{"type":"text","text":"b"}],"usage":{"input_tokens":10,"output_tokens":1000}}}'
+exec sleep 30
+`
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestClientQueryBudget(t *testing.T) {
+	tests := []struct {
+		name    string
+		options *QueryOptions
+	}{
+		// Each message costs 10 * $3 + 1000 * $15 per million tokens = $0.01503
+		{name: "cost limit", options: NewQueryOptions(WithMaxBudgetUSD(0.02))},
+		{name: "token limit", options: NewQueryOptions(WithMaxTokens(1500))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(WithCLIPath(writeSpendingCLI(t)))
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			messages, err := client.Query(ctx, "work", tt.options)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+
+			var received []Message
+			for message := range messages {
+				received = append(received, message)
+			}
+			if ctx.Err() != nil {
+				t.Fatal("query was not stopped after exceeding its budget")
+			}
+			if len(received) != 4 {
+				t.Fatalf("got %d messages, want init, two assistant messages, and an error", len(received))
+			}
+
+			errMsg, ok := received[3].(*ErrorMessage)
+			if !ok {
+				t.Fatalf("last message = %T, want *ErrorMessage", received[3])
+			}
+			var budgetErr *BudgetExceededError
+			if !errors.As(errMsg, &budgetErr) {
+				t.Fatalf("ErrorMessage.Err = %v, want *BudgetExceededError", errMsg.Err)
+			}
+			if budgetErr.Tokens != 2020 || budgetErr.CostUSD < 0.03 || budgetErr.CostUSD > 0.031 {
+				t.Errorf("BudgetExceededError = %+v, want spend of both messages", budgetErr)
+			}
+
+			assistantMsg := received[1].(*AssistantMessage)
+			if assistantMsg.ID != "msg_1" || assistantMsg.Usage == nil || assistantMsg.Usage.OutputTokens != 1000 {
+				t.Errorf("AssistantMessage = %+v, want ID and usage", assistantMsg)
+			}
+		})
+	}
+}
+
+func TestClientSharedBudget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fake-claude")
+	script := `#!/bin/sh
+if [ "$1" = "--version" ]; then echo "2.0.0 (Claude Code)"; exit 0; fi
+echo '{"type":"result","subtype":"success","duration_ms":10,"duration_api_ms":5,"is_error":false,"num_turns":1,"session_id":"s1","total_cost_usd":0.03,"usage":{"input_tokens":100,"output_tokens":200}}'
+`
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	shared := NewBudget(BudgetLimits{MaxCostUSD: 0.05})
+	client := NewClient(WithCLIPath(path), WithBudget(shared))
+	ctx := context.Background()
+
+	for i := range 2 {
+		messages, err := client.Query(ctx, "work", nil)
+		if err != nil {
+			t.Fatalf("query %d: Query() error = %v", i, err)
+		}
+		for range messages {
+		}
+	}
+
+	cost, tokens := shared.Spent()
+	if cost < 0.0599 || cost > 0.0601 || tokens != 600 {
+		t.Errorf("Spent() = %v, %d; want 0.06, 600", cost, tokens)
+	}
+	if !shared.Depleted() {
+		t.Error("Depleted() = false, want true")
+	}
+
+	_, err := client.Query(ctx, "work", nil)
+	var budgetErr *BudgetExceededError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("Query() on a depleted budget error = %v, want *BudgetExceededError", err)
+	}
+	if budgetErr.MaxCostUSD != 0.05 {
+		t.Errorf("BudgetExceededError.MaxCostUSD = %v, want 0.05", budgetErr.MaxCostUSD)
+	}
+}
@@ -0,0 +1,114 @@
+package claudecode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/checkpoint"
+	sdkerrors "github.com/musaprg/claude-code-sdk-go/internal/errors"
+)
+
+// Re-export workspace checkpoint types from internal package.
+// Checkpoints snapshot a working directory so that the changes made by a query can be undone.
+type (
+	// Checkpoint is a snapshot of a directory that can be restored later.
+	Checkpoint = checkpoint.Checkpoint
+	// CheckpointKind identifies how a checkpoint was taken.
+	CheckpointKind = checkpoint.Kind
+)
+
+// Re-export checkpoint kind constants
+const (
+	CheckpointKindGit     = checkpoint.KindGit
+	CheckpointKindArchive = checkpoint.KindArchive
+)
+
+var (
+	// CreateCheckpoint snapshots a directory, using git objects inside a repository
+	// and a temporary archive otherwise.
+	CreateCheckpoint = checkpoint.Create
+)
+
+// ResultValidator inspects the messages of a completed query. Returning an error rejects the result.
+type ResultValidator func(messages []Message) error
+
+// Checkpoint snapshots the working directory a query with the given options would run in.
+// The caller is responsible for calling Discard on the returned checkpoint.
+func (c *Client) Checkpoint(ctx context.Context, options *QueryOptions) (*Checkpoint, error) {
+	return checkpoint.Create(ctx, c.workDir(c.defaults.Merge(options)))
+}
+
+// QueryWithCheckpoint checkpoints the working directory, runs the query to completion, and
+// collects its messages. The workspace is restored to the checkpoint and a *RollbackError is
+// returned if the query ends without a result, is stopped by the SDK (e.g. for exceeding its
+// budget), its ResultMessage reports an error, or validate (which may be nil) rejects the messages.
+// The collected messages are returned in all cases.
+func (c *Client) QueryWithCheckpoint(ctx context.Context, prompt *Prompt, options *QueryOptions, validate ResultValidator) ([]Message, error) {
+	cp, err := c.Checkpoint(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	defer cp.Discard()
+
+	messageCh, err := c.QueryPrompt(ctx, prompt, options)
+	if err != nil {
+		// The CLI never started, so there is nothing to undo
+		return nil, err
+	}
+
+	var messages []Message
+	for message := range messageCh {
+		messages = append(messages, message)
+	}
+
+	reason := rejectResult(ctx, messages, validate)
+	if reason == nil {
+		return messages, nil
+	}
+
+	// Restore even if the query was cancelled
+	if err := cp.Restore(context.WithoutCancel(ctx)); err != nil {
+		return messages, errors.Join(reason, err)
+	}
+	return messages, sdkerrors.NewRollbackError(cp.Dir(), reason)
+}
+
+// rejectResult returns the reason to roll back a completed query, or nil to keep its changes.
+func rejectResult(ctx context.Context, messages []Message, validate ResultValidator) error {
+	var result *ResultMessage
+	for _, message := range messages {
+		switch msg := message.(type) {
+		case *ResultMessage:
+			result = msg
+		case *ErrorMessage:
+			// The query was stopped by the SDK, e.g. because it exceeded its budget
+			return msg.Err
+		}
+	}
+
+	switch {
+	case result == nil:
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return errors.New("query ended without a result")
+	case result.IsError:
+		return fmt.Errorf("query finished with error result %q", result.Subtype)
+	case validate != nil:
+		return validate(messages)
+	default:
+		return nil
+	}
+}
+
+// workDir returns the directory a query with the given merged options runs in.
+func (c *Client) workDir(options *QueryOptions) string {
+	if options != nil && options.CWD != "" {
+		return options.CWD
+	}
+	if c.cwd != "" {
+		return c.cwd
+	}
+	return "."
+}
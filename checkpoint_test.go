@@ -0,0 +1,80 @@
+package claudecode
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeEditingCLI writes a fake CLI that modifies file.txt in its working directory
+// and then reports the given result.
+func writeEditingCLI(t *testing.T, isError bool) string {
+	t.Helper()
+	result := `{"type":"result","subtype":"success","duration_ms":10,"duration_api_ms":5,"is_error":false,"num_turns":1,"session_id":"s1"}`
+	if isError {
+		result = `{"type":"result","subtype":"error_during_execution","duration_ms":10,"duration_api_ms":5,"is_error":true,"num_turns":1,"session_id":"s1"}`
+	}
+	path := filepath.Join(t.TempDir(), "fake-claude")
+	script := "#!/bin/sh\n" +
+		`if [ "$1" = "--version" ]; then echo "2.0.0 (Claude Code)"; exit 0; fi` + "\n" +
+		"echo modified > file.txt\n" +
+		"echo '" + result + "'\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestClientQueryWithCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	rejected := errors.New("tests failed")
+
+	tests := []struct {
+		name     string
+		isError  bool
+		validate ResultValidator
+		wantErr  error
+		want     string
+	}{
+		{name: "success keeps changes", want: "modified\n"},
+		{name: "error result rolls back", isError: true, want: "original"},
+		{
+			name:     "validator rejection rolls back",
+			validate: func([]Message) error { return rejected },
+			wantErr:  rejected,
+			want:     "original",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "file.txt")
+			if err := os.WriteFile(path, []byte("original"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			client := NewClient(WithCLIPath(writeEditingCLI(t, tt.isError)), WithCWD(dir))
+
+			messages, err := client.QueryWithCheckpoint(ctx, NewPrompt().Text("edit"), nil, tt.validate)
+			if len(messages) != 1 {
+				t.Errorf("got %d messages, want 1", len(messages))
+			}
+
+			rolledBack := tt.want == "original"
+			var rollbackErr *RollbackError
+			if errors.As(err, &rollbackErr) != rolledBack {
+				t.Errorf("QueryWithCheckpoint() error = %v, want RollbackError: %v", err, rolledBack)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("QueryWithCheckpoint() error = %v, want to wrap %v", err, tt.wantErr)
+			}
+
+			got, _ := os.ReadFile(path)
+			if string(got) != tt.want {
+				t.Errorf("file.txt = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
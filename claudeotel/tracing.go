@@ -0,0 +1,227 @@
+// Package claudeotel traces Claude Code queries with OpenTelemetry.
+//
+// Each query is recorded as a span with a child span per assistant turn and per tool call:
+//
+//	client := claudecode.NewClient(claudecode.WithObserver(claudeotel.NewObserver(otel.GetTracerProvider())))
+package claudeotel
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	claudecode "github.com/musaprg/claude-code-sdk-go"
+)
+
+// ScopeName is the instrumentation scope of the tracer.
+const ScopeName = "github.com/musaprg/claude-code-sdk-go/claudeotel"
+
+// Span attribute keys. Keys from the OpenTelemetry GenAI semantic conventions are used where they apply.
+const (
+	AttrSystem              = attribute.Key("gen_ai.system")
+	AttrOperation           = attribute.Key("gen_ai.operation.name")
+	AttrRequestModel        = attribute.Key("gen_ai.request.model")
+	AttrResponseModel       = attribute.Key("gen_ai.response.model")
+	AttrResponseID          = attribute.Key("gen_ai.response.id")
+	AttrConversationID      = attribute.Key("gen_ai.conversation.id")
+	AttrInputTokens         = attribute.Key("gen_ai.usage.input_tokens")
+	AttrOutputTokens        = attribute.Key("gen_ai.usage.output_tokens")
+	AttrCacheCreationTokens = attribute.Key("claude.usage.cache_creation_input_tokens")
+	AttrCacheReadTokens     = attribute.Key("claude.usage.cache_read_input_tokens")
+	AttrToolName            = attribute.Key("gen_ai.tool.name")
+	AttrToolCallID          = attribute.Key("gen_ai.tool.call.id")
+	AttrToolIsError         = attribute.Key("claude.tool.is_error")
+	AttrTurn                = attribute.Key("claude.turn")
+	AttrResultSubtype       = attribute.Key("claude.result.subtype")
+	AttrResultIsError       = attribute.Key("claude.result.is_error")
+	AttrCostUSD             = attribute.Key("claude.cost_usd")
+	AttrNumTurns            = attribute.Key("claude.num_turns")
+	AttrDurationMs          = attribute.Key("claude.duration_ms")
+	AttrDurationAPIMs       = attribute.Key("claude.duration_api_ms")
+)
+
+// Observer is a claudecode.Observer that records queries as OpenTelemetry spans.
+type Observer struct {
+	tracer trace.Tracer
+}
+
+// NewObserver creates an Observer that creates spans with a tracer from provider.
+func NewObserver(provider trace.TracerProvider) *Observer {
+	return &Observer{tracer: provider.Tracer(ScopeName)}
+}
+
+// StartQuery starts the span of a query as a child of the span in ctx, if any.
+func (o *Observer) StartQuery(ctx context.Context, options *claudecode.QueryOptions) claudecode.QueryObserver {
+	attrs := []attribute.KeyValue{AttrSystem.String("anthropic"), AttrOperation.String("invoke_agent")}
+	if options.Model != "" {
+		attrs = append(attrs, AttrRequestModel.String(options.Model))
+	}
+	if options.Resume != "" {
+		attrs = append(attrs, AttrConversationID.String(options.Resume))
+	}
+
+	ctx, span := o.tracer.Start(ctx, "claude.query", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return &query{
+		tracer:  o.tracer,
+		ctx:     ctx,
+		span:    span,
+		waiting: time.Now(),
+		tools:   make(map[string]trace.Span),
+	}
+}
+
+// query records the spans of a single query.
+type query struct {
+	tracer trace.Tracer
+	// ctx carries the query span.
+	ctx  context.Context
+	span trace.Span
+
+	// turn is the span of the current assistant turn, or nil between turns.
+	turn    trace.Span
+	turnCtx context.Context
+	turnID  string
+	turns   int
+	// waiting is when the agent last started waiting for the model, which is when the next turn begins.
+	waiting time.Time
+
+	// tools holds the spans of tool calls awaiting their results, keyed by tool use ID.
+	tools map[string]trace.Span
+}
+
+func (q *query) Message(message claudecode.Message) {
+	switch msg := message.(type) {
+	case *claudecode.SystemMessage:
+		if sessionID, ok := msg.Data["session_id"].(string); ok && sessionID != "" {
+			q.span.SetAttributes(AttrConversationID.String(sessionID))
+		}
+	case *claudecode.AssistantMessage:
+		// The CLI reports each content block of an API message separately
+		if q.turn == nil || msg.ID == "" || msg.ID != q.turnID {
+			q.endTurn()
+			q.startTurn(msg)
+		}
+		if msg.Usage != nil {
+			q.turn.SetAttributes(usageAttributes(msg.Usage.InputTokens, msg.Usage.OutputTokens,
+				msg.Usage.CacheCreationInputTokens, msg.Usage.CacheReadInputTokens)...)
+		}
+		for _, block := range msg.Content {
+			if toolUse, ok := block.(*claudecode.ToolUseBlock); ok {
+				q.startTool(toolUse)
+			}
+		}
+	case *claudecode.UserMessage:
+		q.endTurn()
+		for _, block := range msg.ContentBlocks {
+			if toolResult, ok := block.(*claudecode.ToolResultBlock); ok {
+				q.endTool(toolResult)
+			}
+		}
+		q.waiting = time.Now()
+	case *claudecode.ResultMessage:
+		q.endTurn()
+		q.recordResult(msg)
+	}
+}
+
+func (q *query) End(err error) {
+	q.endTurn()
+	for id, span := range q.tools {
+		span.SetStatus(codes.Error, "query ended before the tool returned a result")
+		span.End()
+		delete(q.tools, id)
+	}
+	if err != nil {
+		q.span.RecordError(err)
+		q.span.SetStatus(codes.Error, err.Error())
+	}
+	q.span.End()
+}
+
+func (q *query) startTurn(msg *claudecode.AssistantMessage) {
+	q.turns++
+	attrs := []attribute.KeyValue{AttrTurn.Int(q.turns)}
+	if msg.ID != "" {
+		attrs = append(attrs, AttrResponseID.String(msg.ID))
+	}
+	if msg.Model != "" {
+		attrs = append(attrs, AttrResponseModel.String(msg.Model))
+	}
+	// The turn began when the model was asked, not when its first content block arrived
+	q.turnCtx, q.turn = q.tracer.Start(q.ctx, "claude.turn", trace.WithTimestamp(q.waiting), trace.WithAttributes(attrs...))
+	q.turnID = msg.ID
+}
+
+func (q *query) endTurn() {
+	if q.turn == nil {
+		return
+	}
+	q.turn.End()
+	q.turn, q.turnCtx, q.turnID = nil, nil, ""
+	q.waiting = time.Now()
+}
+
+func (q *query) startTool(toolUse *claudecode.ToolUseBlock) {
+	_, span := q.tracer.Start(q.turnCtx, "claude.tool "+toolUse.Name,
+		trace.WithAttributes(AttrToolName.String(toolUse.Name), AttrToolCallID.String(toolUse.ID)))
+	q.tools[toolUse.ID] = span
+}
+
+func (q *query) endTool(toolResult *claudecode.ToolResultBlock) {
+	span, ok := q.tools[toolResult.ToolUseID]
+	if !ok {
+		return
+	}
+	delete(q.tools, toolResult.ToolUseID)
+
+	isError := toolResult.IsError != nil && *toolResult.IsError
+	span.SetAttributes(AttrToolIsError.Bool(isError))
+	if isError {
+		span.SetStatus(codes.Error, "tool returned an error")
+	}
+	span.End()
+}
+
+func (q *query) recordResult(result *claudecode.ResultMessage) {
+	attrs := []attribute.KeyValue{
+		AttrResultSubtype.String(result.Subtype),
+		AttrResultIsError.Bool(result.IsError),
+		AttrNumTurns.Int(result.NumTurns),
+		AttrDurationMs.Int(result.DurationMs),
+		AttrDurationAPIMs.Int(result.DurationAPIMs),
+	}
+	if result.SessionID != "" {
+		attrs = append(attrs, AttrConversationID.String(result.SessionID))
+	}
+	if result.TotalCostUSD != nil {
+		attrs = append(attrs, AttrCostUSD.Float64(*result.TotalCostUSD))
+	}
+	attrs = append(attrs, usageAttributes(
+		usageCount(result.Usage, "input_tokens"),
+		usageCount(result.Usage, "output_tokens"),
+		usageCount(result.Usage, "cache_creation_input_tokens"),
+		usageCount(result.Usage, "cache_read_input_tokens"))...)
+	q.span.SetAttributes(attrs...)
+
+	if result.IsError {
+		q.span.SetStatus(codes.Error, result.Subtype)
+	}
+}
+
+func usageAttributes(input, output, cacheCreation, cacheRead int) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		AttrInputTokens.Int(input),
+		AttrOutputTokens.Int(output),
+		AttrCacheCreationTokens.Int(cacheCreation),
+		AttrCacheReadTokens.Int(cacheRead),
+	}
+}
+
+// usageCount returns a token count from a ResultMessage usage map.
+func usageCount(usage map[string]any, key string) int {
+	count, _ := usage[key].(float64)
+	return int(count)
+}
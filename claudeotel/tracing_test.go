@@ -0,0 +1,144 @@
+package claudeotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	claudecode "github.com/musaprg/claude-code-sdk-go"
+)
+
+func newTestObserver(t *testing.T) (*Observer, *tracetest.SpanRecorder) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return NewObserver(provider), recorder
+}
+
+func spansByName(recorder *tracetest.SpanRecorder) map[string]sdktrace.ReadOnlySpan {
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	return spans
+}
+
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestObserver(t *testing.T) {
+	observer, recorder := newTestObserver(t)
+	isError := true
+	cost := 0.25
+	result := "done"
+
+	query := observer.StartQuery(context.Background(), &claudecode.QueryOptions{Model: "sonnet"})
+	for _, message := range []claudecode.Message{
+		claudecode.NewSystemMessage("init", map[string]any{"session_id": "s1"}),
+		// Two content blocks of the same API message form one turn
+		&claudecode.AssistantMessage{ID: "msg_1", Model: "claude-sonnet-4-5", Content: []claudecode.ContentBlock{
+			claudecode.NewTextBlock("Let me look"),
+		}},
+		&claudecode.AssistantMessage{ID: "msg_1", Model: "claude-sonnet-4-5", Content: []claudecode.ContentBlock{
+			claudecode.NewToolUseBlock("tool_1", "Bash", map[string]any{"command": "false"}),
+		}, Usage: &claudecode.Usage{InputTokens: 10, OutputTokens: 20}},
+		&claudecode.UserMessage{ContentBlocks: []claudecode.ContentBlock{
+			&claudecode.ToolResultBlock{ToolUseID: "tool_1", Content: "exit status 1", IsError: &isError},
+		}},
+		&claudecode.AssistantMessage{ID: "msg_2", Content: []claudecode.ContentBlock{claudecode.NewTextBlock("Done")}},
+		&claudecode.ResultMessage{Subtype: "success", NumTurns: 2, DurationMs: 1200, SessionID: "s1", TotalCostUSD: &cost,
+			Usage: map[string]any{"input_tokens": 30.0, "output_tokens": 40.0}, Result: &result},
+	} {
+		query.Message(message)
+	}
+	query.End(nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("got %d spans, want query, two turns, and a tool", len(spans))
+	}
+	byName := spansByName(recorder)
+
+	root := byName["claude.query"]
+	if root == nil {
+		t.Fatal("no claude.query span")
+	}
+	if got := attr(root, AttrRequestModel).AsString(); got != "sonnet" {
+		t.Errorf("query model = %q, want sonnet", got)
+	}
+	if got := attr(root, AttrConversationID).AsString(); got != "s1" {
+		t.Errorf("query session = %q, want s1", got)
+	}
+	if got := attr(root, AttrCostUSD).AsFloat64(); got != 0.25 {
+		t.Errorf("query cost = %v, want 0.25", got)
+	}
+	if got := attr(root, AttrOutputTokens).AsInt64(); got != 40 {
+		t.Errorf("query output tokens = %d, want 40", got)
+	}
+	if got := attr(root, AttrNumTurns).AsInt64(); got != 2 {
+		t.Errorf("query turns = %d, want 2", got)
+	}
+	if root.Status().Code == codes.Error {
+		t.Errorf("query status = %v, want unset", root.Status())
+	}
+
+	tool := byName["claude.tool Bash"]
+	if tool == nil {
+		t.Fatal("no tool span")
+	}
+	if !attr(tool, AttrToolIsError).AsBool() || tool.Status().Code != codes.Error {
+		t.Error("failed tool call is not marked as an error")
+	}
+
+	var turns []sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		if span.Name() == "claude.turn" {
+			turns = append(turns, span)
+			if span.Parent().SpanID() != root.SpanContext().SpanID() {
+				t.Error("turn span is not a child of the query span")
+			}
+		}
+	}
+	if len(turns) != 2 {
+		t.Fatalf("got %d turns, want 2", len(turns))
+	}
+	if tool.Parent().SpanID() != turns[0].SpanContext().SpanID() {
+		t.Error("tool span is not a child of the turn that called it")
+	}
+	if got := attr(turns[0], AttrInputTokens).AsInt64(); got != 10 {
+		t.Errorf("turn input tokens = %d, want 10", got)
+	}
+}
+
+func TestObserverError(t *testing.T) {
+	observer, recorder := newTestObserver(t)
+
+	query := observer.StartQuery(context.Background(), &claudecode.QueryOptions{})
+	query.Message(&claudecode.AssistantMessage{ID: "msg_1", Content: []claudecode.ContentBlock{
+		claudecode.NewToolUseBlock("tool_1", "Read", nil),
+	}})
+	query.End(errors.New("process failed"))
+
+	byName := spansByName(recorder)
+	if len(byName) != 3 {
+		t.Fatalf("got %d spans, want query, turn, and tool", len(byName))
+	}
+	if status := byName["claude.query"].Status(); status.Code != codes.Error || status.Description != "process failed" {
+		t.Errorf("query status = %v, want the error", status)
+	}
+	if byName["claude.tool Read"].Status().Code != codes.Error {
+		t.Error("unfinished tool call is not marked as an error")
+	}
+}
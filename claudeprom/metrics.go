@@ -0,0 +1,176 @@
+// Package claudeprom exports Claude Code client metrics to Prometheus.
+//
+//	metrics := claudeprom.NewMetrics(nil)
+//	prometheus.MustRegister(metrics)
+//	client := claudecode.NewClient(claudecode.WithMetrics(metrics))
+package claudeprom
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	claudecode "github.com/musaprg/claude-code-sdk-go"
+)
+
+// DefaultNamespace prefixes the names of all metrics.
+const DefaultNamespace = "claude_code"
+
+// DefaultModelLabel is the model label of queries that use the CLI's default model.
+const DefaultModelLabel = "default"
+
+// Options configures Metrics.
+type Options struct {
+	// Namespace prefixes the names of all metrics. Defaults to DefaultNamespace.
+	Namespace string
+	// ConstLabels are added to all metrics, e.g. to tell services apart.
+	ConstLabels prometheus.Labels
+	// DurationBuckets are the histogram buckets of query durations in seconds.
+	// Defaults to buckets from 1 second to about 30 minutes.
+	DurationBuckets []float64
+	// LatencyBuckets are the histogram buckets of process start and first message latencies
+	// in seconds. Defaults to prometheus.DefBuckets.
+	LatencyBuckets []float64
+}
+
+// Metrics is a claudecode.Metrics that records measurements as Prometheus metrics.
+// It is a prometheus.Collector and must be registered to be exported.
+type Metrics struct {
+	queriesStarted    *prometheus.CounterVec
+	queriesFinished   *prometheus.CounterVec
+	queryDuration     *prometheus.HistogramVec
+	processStart      prometheus.Histogram
+	firstMessage      *prometheus.HistogramVec
+	toolCalls         *prometheus.CounterVec
+	tokens            *prometheus.CounterVec
+	cost              *prometheus.CounterVec
+	stderrTruncations prometheus.Counter
+}
+
+var _ claudecode.Metrics = (*Metrics)(nil)
+
+// NewMetrics creates Metrics with the given options, which may be nil.
+func NewMetrics(options *Options) *Metrics {
+	o := Options{}
+	if options != nil {
+		o = *options
+	}
+	if o.Namespace == "" {
+		o.Namespace = DefaultNamespace
+	}
+	if o.DurationBuckets == nil {
+		o.DurationBuckets = prometheus.ExponentialBuckets(1, 2, 12)
+	}
+	if o.LatencyBuckets == nil {
+		o.LatencyBuckets = prometheus.DefBuckets
+	}
+
+	return &Metrics{
+		queriesStarted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.Namespace, Name: "queries_started_total", ConstLabels: o.ConstLabels,
+			Help: "Number of queries started.",
+		}, []string{"model"}),
+		queriesFinished: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.Namespace, Name: "queries_finished_total", ConstLabels: o.ConstLabels,
+			Help: "Number of queries finished, by failure reason (empty for successful queries).",
+		}, []string{"model", "failure"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.Namespace, Name: "query_duration_seconds", ConstLabels: o.ConstLabels,
+			Help: "Duration of queries.", Buckets: o.DurationBuckets,
+		}, []string{"model"}),
+		processStart: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: o.Namespace, Name: "process_start_seconds", ConstLabels: o.ConstLabels,
+			Help: "Time to locate, check, and launch the CLI.", Buckets: o.LatencyBuckets,
+		}),
+		firstMessage: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.Namespace, Name: "first_message_seconds", ConstLabels: o.ConstLabels,
+			Help: "Time from the start of a query to its first message.", Buckets: o.LatencyBuckets,
+		}, []string{"model"}),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.Namespace, Name: "tool_calls_total", ConstLabels: o.ConstLabels,
+			Help: "Number of tool calls that returned a result.",
+		}, []string{"tool", "is_error"}),
+		tokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.Namespace, Name: "tokens_total", ConstLabels: o.ConstLabels,
+			Help: "Number of tokens used, by responding model and token type.",
+		}, []string{"model", "type"}),
+		cost: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.Namespace, Name: "cost_usd_total", ConstLabels: o.ConstLabels,
+			Help: "Cost in USD, by responding model.",
+		}, []string{"model"}),
+		stderrTruncations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: o.Namespace, Name: "stderr_truncations_total", ConstLabels: o.ConstLabels,
+			Help: "Number of CLI processes whose error output was truncated.",
+		}),
+	}
+}
+
+func (m *Metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.queriesStarted, m.queriesFinished, m.queryDuration, m.processStart, m.firstMessage,
+		m.toolCalls, m.tokens, m.cost, m.stderrTruncations,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range m.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range m.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+// QueryStarted implements claudecode.Metrics.
+func (m *Metrics) QueryStarted(model string) {
+	m.queriesStarted.WithLabelValues(modelLabel(model)).Inc()
+}
+
+// QueryFinished implements claudecode.Metrics.
+func (m *Metrics) QueryFinished(model string, duration time.Duration, failure string) {
+	m.queriesFinished.WithLabelValues(modelLabel(model), failure).Inc()
+	m.queryDuration.WithLabelValues(modelLabel(model)).Observe(duration.Seconds())
+}
+
+// ProcessStarted implements claudecode.Metrics.
+func (m *Metrics) ProcessStarted(latency time.Duration) {
+	m.processStart.Observe(latency.Seconds())
+}
+
+// FirstMessage implements claudecode.Metrics.
+func (m *Metrics) FirstMessage(model string, latency time.Duration) {
+	m.firstMessage.WithLabelValues(modelLabel(model)).Observe(latency.Seconds())
+}
+
+// ToolCall implements claudecode.Metrics.
+func (m *Metrics) ToolCall(tool string, isError bool) {
+	m.toolCalls.WithLabelValues(tool, strconv.FormatBool(isError)).Inc()
+}
+
+// Usage implements claudecode.Metrics.
+func (m *Metrics) Usage(model string, usage claudecode.Usage, costUSD float64) {
+	model = modelLabel(model)
+	m.tokens.WithLabelValues(model, "input").Add(float64(usage.InputTokens))
+	m.tokens.WithLabelValues(model, "output").Add(float64(usage.OutputTokens))
+	m.tokens.WithLabelValues(model, "cache_creation").Add(float64(usage.CacheCreationInputTokens))
+	m.tokens.WithLabelValues(model, "cache_read").Add(float64(usage.CacheReadInputTokens))
+	m.cost.WithLabelValues(model).Add(costUSD)
+}
+
+// StderrTruncated implements claudecode.Metrics.
+func (m *Metrics) StderrTruncated() {
+	m.stderrTruncations.Inc()
+}
+
+func modelLabel(model string) string {
+	if model == "" {
+		return DefaultModelLabel
+	}
+	return model
+}
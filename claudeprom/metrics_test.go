@@ -0,0 +1,73 @@
+package claudeprom
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	claudecode "github.com/musaprg/claude-code-sdk-go"
+)
+
+// writeToolCLI writes a fake CLI that runs one failing Bash tool call and succeeds.
+func writeToolCLI(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fake-claude")
+	script := `#!/bin/sh
+if [ "$1" = "--version" ]; then echo "2.0.0 (Claude Code)"; exit 0; fi
+echo '{"type":"system","subtype":"init","session_id":"s1"}'
+echo '{"type":"assistant","message":{"id":"msg_1","model":"claude-sonnet-4-5","content":[{"type":"tool_use","id":"tool_1","name":"Bash","input":{"command":"false"}}],"usage":{"input_tokens":1000,"output_tokens":2000}}}'
+echo '{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"tool_1","content":"exit status 1","is_error":true}]}}'
+echo '{"type":"result","subtype":"success","duration_ms":10,"duration_api_ms":5,"is_error":false,"num_turns":2,"session_id":"s1"}'
+`
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics(&Options{Namespace: "test"})
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(metrics)
+
+	client := claudecode.NewClient(claudecode.WithCLIPath(writeToolCLI(t)), claudecode.WithMetrics(metrics))
+	messages, err := client.Query(context.Background(), "work", nil)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	for range messages {
+	}
+
+	expected := `
+# HELP test_cost_usd_total Cost in USD, by responding model.
+# TYPE test_cost_usd_total counter
+test_cost_usd_total{model="claude-sonnet-4-5"} 0.033
+# HELP test_queries_finished_total Number of queries finished, by failure reason (empty for successful queries).
+# TYPE test_queries_finished_total counter
+test_queries_finished_total{failure="",model="default"} 1
+# HELP test_queries_started_total Number of queries started.
+# TYPE test_queries_started_total counter
+test_queries_started_total{model="default"} 1
+# HELP test_tool_calls_total Number of tool calls that returned a result.
+# TYPE test_tool_calls_total counter
+test_tool_calls_total{is_error="true",tool="Bash"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"test_cost_usd_total", "test_queries_finished_total", "test_queries_started_total", "test_tool_calls_total"); err != nil {
+		t.Error(err)
+	}
+
+	if got := testutil.ToFloat64(metrics.tokens.WithLabelValues("claude-sonnet-4-5", "output")); got != 2000 {
+		t.Errorf("output tokens = %v, want 2000", got)
+	}
+	for _, name := range []string{"test_process_start_seconds", "test_first_message_seconds", "test_query_duration_seconds"} {
+		if count, err := testutil.GatherAndCount(registry, name); err != nil || count != 1 {
+			t.Errorf("%s has %d series (err %v), want 1", name, count, err)
+		}
+	}
+}
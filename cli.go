@@ -0,0 +1,33 @@
+package claudecode
+
+import "github.com/musaprg/claude-code-sdk-go/internal/cli"
+
+// Re-export CLI discovery and version detection from internal package.
+// These helpers let applications inspect the installed Claude Code CLI before running queries.
+type (
+	// CLIVersion is a semantic version of the Claude Code CLI.
+	CLIVersion = cli.SemVer
+	// CLIFeatures describes which optional CLI capabilities are available in a given CLI version.
+	CLIFeatures = cli.Features
+)
+
+// CLIPathEnv is the environment variable that overrides CLI discovery.
+// When set, FindCLI uses its value instead of searching PATH and standard locations.
+const CLIPathEnv = cli.CLIPathEnv
+
+// MinimumCLIVersion is the oldest Claude Code CLI release supported by this SDK.
+// Queries against older CLIs fail with a CLIVersionError unless ClientOptions.SkipVersionCheck is set.
+const MinimumCLIVersion = cli.MinimumCLIVersion
+
+var (
+	// FindCLI locates the Claude Code CLI executable via CLIPathEnv, PATH, and standard installation locations.
+	FindCLI = cli.FindCLI
+	// CLISearchPaths returns the standard installation locations checked by FindCLI after PATH.
+	CLISearchPaths = cli.SearchPaths
+	// DetectCLIVersion runs "claude --version" for the CLI at the given path and caches the result.
+	DetectCLIVersion = cli.Version
+	// ParseCLIVersion extracts a CLIVersion from a version string such as "1.0.88 (Claude Code)".
+	ParseCLIVersion = cli.ParseVersion
+	// CLIFeaturesFor returns the optional features supported by the given CLI version.
+	CLIFeaturesFor = cli.FeaturesFor
+)
@@ -0,0 +1,159 @@
+package claudecode
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseCLIVersion(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    CLIVersion
+		wantErr bool
+	}{
+		{input: "1.0.88 (Claude Code)", want: CLIVersion{Major: 1, Minor: 0, Patch: 88}},
+		{input: "2.10.3\n", want: CLIVersion{Major: 2, Minor: 10, Patch: 3}},
+		{input: "claude version unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseCLIVersion(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCLIVersion(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseCLIVersion(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCLIVersionCompare(t *testing.T) {
+	older := CLIVersion{Major: 1, Minor: 0, Patch: 9}
+	newer := CLIVersion{Major: 1, Minor: 0, Patch: 10}
+
+	if older.Compare(newer) != -1 || newer.Compare(older) != 1 || older.Compare(older) != 0 {
+		t.Errorf("CLIVersion.Compare() ordering is wrong for %v and %v", older, newer)
+	}
+	if !newer.AtLeast(older) || older.AtLeast(newer) {
+		t.Errorf("CLIVersion.AtLeast() is wrong for %v and %v", older, newer)
+	}
+}
+
+func TestCLIFeaturesFor(t *testing.T) {
+	oldFeatures := CLIFeaturesFor(CLIVersion{Major: 1, Minor: 0, Patch: 0})
+	if oldFeatures.PartialMessages || oldFeatures.SystemPromptFile {
+		t.Errorf("CLIFeaturesFor(1.0.0) = %+v, want no optional features", oldFeatures)
+	}
+
+	newFeatures := CLIFeaturesFor(CLIVersion{Major: 2, Minor: 0, Patch: 0})
+	if !newFeatures.PartialMessages || !newFeatures.SystemPromptFile {
+		t.Errorf("CLIFeaturesFor(2.0.0) = %+v, want all optional features", newFeatures)
+	}
+}
+
+func TestDetectCLIVersion(t *testing.T) {
+	dir := t.TempDir()
+	cliPath := filepath.Join(dir, "claude")
+	countPath := filepath.Join(dir, "count")
+	script := "#!/bin/sh\necho x >> " + countPath + "\necho '1.0.90 (Claude Code)'\n"
+	if err := os.WriteFile(cliPath, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 3 {
+		v, err := DetectCLIVersion(cliPath)
+		if err != nil {
+			t.Fatalf("DetectCLIVersion() error = %v", err)
+		}
+		if v.String() != "1.0.90" {
+			t.Errorf("DetectCLIVersion() = %v, want 1.0.90", v)
+		}
+	}
+
+	count, err := os.ReadFile(countPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(count) != len("x\n") {
+		t.Errorf("CLI was invoked %d times, want 1 (result should be cached)", len(count)/2)
+	}
+
+	_, err = DetectCLIVersion(filepath.Join(dir, "missing"))
+	var versionErr *CLIVersionError
+	if !errors.As(err, &versionErr) {
+		t.Errorf("DetectCLIVersion(missing) error = %v, want *CLIVersionError", err)
+	}
+}
+
+func TestFindCLI(t *testing.T) {
+	writeExecutable := func(t *testing.T, path string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("environment override", func(t *testing.T) {
+		cliPath := filepath.Join(t.TempDir(), "claude")
+		writeExecutable(t, cliPath)
+		t.Setenv(CLIPathEnv, cliPath)
+
+		got, err := FindCLI()
+		if err != nil {
+			t.Fatalf("FindCLI() error = %v", err)
+		}
+		if got != cliPath {
+			t.Errorf("FindCLI() = %q, want %q", got, cliPath)
+		}
+	})
+
+	t.Run("environment override missing", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "claude")
+		t.Setenv(CLIPathEnv, missing)
+
+		_, err := FindCLI()
+		var notFound *CLINotFoundError
+		if !errors.As(err, &notFound) {
+			t.Fatalf("FindCLI() error = %v, want *CLINotFoundError", err)
+		}
+		if notFound.CLIPath != missing {
+			t.Errorf("CLINotFoundError.CLIPath = %q, want %q", notFound.CLIPath, missing)
+		}
+	})
+
+	t.Run("search paths", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("NVM_DIR", "")
+		t.Setenv("PNPM_HOME", "")
+		older := filepath.Join(home, ".nvm", "versions", "node", "v18.0.0", "bin", "claude")
+		newer := filepath.Join(home, ".nvm", "versions", "node", "v20.0.0", "bin", "claude")
+		writeExecutable(t, older)
+		writeExecutable(t, newer)
+
+		paths := CLISearchPaths(home)
+		wantPaths := []string{
+			filepath.Join(home, ".volta", "bin", "claude"),
+			filepath.Join(home, ".bun", "bin", "claude"),
+			filepath.Join(home, ".local", "share", "pnpm", "claude"),
+			"/opt/homebrew/bin/claude",
+			newer,
+			older,
+		}
+		for _, want := range wantPaths {
+			if !slices.Contains(paths, want) {
+				t.Errorf("CLISearchPaths() = %v, want it to contain %q", paths, want)
+			}
+		}
+		if slices.Index(paths, newer) > slices.Index(paths, older) {
+			t.Errorf("CLISearchPaths() lists %q before %q, want newest Node.js version first", older, newer)
+		}
+	})
+}
@@ -2,9 +2,12 @@ package claudecode
 
 import (
 	"context"
+	"log/slog"
 
+	"github.com/musaprg/claude-code-sdk-go/internal/budget"
+	"github.com/musaprg/claude-code-sdk-go/internal/ratelimit"
+	"github.com/musaprg/claude-code-sdk-go/internal/redact"
 	"github.com/musaprg/claude-code-sdk-go/internal/transport"
-	"github.com/musaprg/claude-code-sdk-go/internal/types"
 )
 
 // Client represents a Claude Code SDK client that manages communication with the Claude Code CLI.
@@ -12,73 +15,288 @@ import (
 type Client struct {
 	// cliPath is the path to the Claude Code CLI executable.
 	cliPath string
+	// nodePath is the Node.js binary used to run a JavaScript cliPath.
+	nodePath string
 	// cwd is the current working directory for Claude Code operations.
 	cwd string
+	// promptDelivery controls whether prompts are passed via argv or stdin.
+	promptDelivery PromptDelivery
+	// mcpConfigDelivery controls whether MCP server configurations are passed via argv or a temporary file.
+	mcpConfigDelivery McpConfigDelivery
+	// skipVersionCheck disables CLI version detection on connect.
+	skipVersionCheck bool
+	// rateLimiter paces the start of queries per model, if set.
+	rateLimiter *ratelimit.Limiter
+	// observer is notified about every query, if set.
+	observer Observer
+	// metrics receives measurements of queries and CLI processes, if set.
+	metrics Metrics
+	// baseLogger receives process lifecycle events and SDK decisions. It discards them by default.
+	// It is passed to transports unscrubbed, which scrub it with redactor and the secrets of their query.
+	baseLogger *slog.Logger
+	// logger is baseLogger scrubbed by redactor, for the records of the client itself.
+	logger *slog.Logger
+	// redactor scrubs secrets from log records and process errors.
+	redactor *Redactor
+	// defaults are the query options every per-query QueryOptions is merged on top of.
+	defaults *QueryOptions
 }
 
 // NewClient creates a new Claude Code SDK client with the given options.
-// If options is nil, default settings will be used (CLI auto-discovery, current working directory).
-func NewClient(options *ClientOptions) *Client {
-	client := &Client{}
-
-	if options != nil {
-		if options.CLIPath != "" {
-			client.cliPath = options.CLIPath
-		}
-		if options.CWD != "" {
-			client.cwd = options.CWD
+// Options are applied in order, so later options override earlier ones. Both functional
+// options (WithModel, WithCWD, ...) and *ClientOptions structs are accepted; nil options are ignored.
+// Without options, default settings will be used (CLI auto-discovery, current working directory).
+func NewClient(opts ...ClientOption) *Client {
+	options := &ClientOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.ApplyClientOption(options)
 		}
 	}
 
-	return client
+	logger := options.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	redactor := options.Redactor
+	if redactor == nil {
+		redactor = redact.New(nil)
+	}
+
+	return &Client{
+		cliPath:           options.CLIPath,
+		nodePath:          options.NodePath,
+		cwd:               options.CWD,
+		promptDelivery:    options.PromptDelivery,
+		mcpConfigDelivery: options.McpConfigDelivery,
+		skipVersionCheck:  options.SkipVersionCheck,
+		rateLimiter:       options.RateLimiter,
+		observer:          options.Observer,
+		metrics:           options.Metrics,
+		baseLogger:        logger,
+		logger:            redactor.Logger(logger),
+		redactor:          redactor,
+		defaults:          options.QueryDefaults,
+	}
 }
 
 // Query sends a prompt to Claude Code and returns a channel that streams response messages.
+// options are merged on top of the client's default query options and may be nil.
 // The returned channel will receive messages as they are generated by Claude Code.
 // The channel will be closed when the conversation completes or the context is cancelled.
+// If QueryOptions.MaxBudgetUSD, MaxTokens, or the remainder of QueryOptions.Budget is exceeded,
+// the CLI is stopped and an *ErrorMessage wrapping a *BudgetExceededError is sent as the last message.
+// If the CLI exits with an error, an *ErrorMessage wrapping a *ProcessError is sent as the last message,
+// unless QueryOptions.Retry retries the query.
 func (c *Client) Query(ctx context.Context, prompt string, options *QueryOptions) (<-chan Message, error) {
-	// Create transport
-	transport := transport.NewSubprocessTransport(c.cliPath, c.cwd)
+	return c.QueryPrompt(ctx, NewPrompt().Text(prompt), options)
+}
+
+// QueryPrompt sends a multimodal prompt composed of text, images, and documents to Claude Code
+// and returns a channel that streams response messages, like Query.
+// Any error recorded while building the prompt is returned before the CLI is started.
+func (c *Client) QueryPrompt(ctx context.Context, prompt *Prompt, options *QueryOptions) (<-chan Message, error) {
+	if err := prompt.Err(); err != nil {
+		return nil, err
+	}
+
+	options = c.defaults.Merge(options)
+
+	// Reject invalid options before paying for a CLI startup
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	observer := c.startObserver(ctx, options)
+	messageCh, err := c.start(ctx, prompt, options)
+	if err != nil {
+		endObserver(observer, err)
+		return nil, err
+	}
+	if options.Retry != nil {
+		messageCh = c.retry(ctx, prompt, options, messageCh)
+	}
+	return observe(ctx, observer, messageCh), nil
+}
 
-	// Convert options to internal type
-	var internalOptions *types.QueryOptions
-	if options != nil {
-		internalOptions = (*types.QueryOptions)(options)
+// start runs a single attempt of a query with merged and validated options.
+func (c *Client) start(ctx context.Context, prompt *Prompt, options *QueryOptions) (<-chan Message, error) {
+	limits, reservation, err := queryLimits(options)
+	if err != nil {
+		return nil, err
 	}
 
+	gate, err := c.reserve(ctx, options)
+	if err != nil {
+		reservation.Release()
+		return nil, err
+	}
+
+	// Create transport
+	transport := c.newTransport()
+
 	// Connect and start the query
-	if err := transport.Connect(ctx, internalOptions, prompt); err != nil {
+	if err := transport.Connect(ctx, options, prompt); err != nil {
+		reservation.Release()
+		gate.cancel()
 		return nil, err
 	}
 
 	// Get message channel
 	messageCh, err := transport.ReceiveMessages(ctx)
 	if err != nil {
-		transport.Disconnect()
+		transport.Close()
+		reservation.Release()
+		gate.cancel()
 		return nil, err
 	}
 
+	return stream(ctx, transport, messageCh, limits, reservation, gate), nil
+}
+
+// newTransport creates a transport configured from the client settings.
+func (c *Client) newTransport() *transport.SubprocessTransport {
+	return transport.NewSubprocessTransport(transport.Config{
+		CLIPath:           c.cliPath,
+		NodePath:          c.nodePath,
+		CWD:               c.cwd,
+		PromptDelivery:    c.promptDelivery,
+		McpConfigDelivery: c.mcpConfigDelivery,
+		SkipVersionCheck:  c.skipVersionCheck,
+		Metrics:           c.metrics,
+		Logger:            c.baseLogger,
+		Redactor:          c.redactor,
+	})
+}
+
+// queryLimits returns the spending limits of a query and, if it has a shared budget, the
+// reservation of the budget it may spend. It refuses the query if the budget is depleted.
+func queryLimits(options *QueryOptions) (budget.Limits, *budget.Reservation, error) {
+	limits := budget.Limits{MaxCostUSD: options.MaxBudgetUSD, MaxTokens: options.MaxTokens}
+	if options.Budget == nil {
+		return limits, nil, nil
+	}
+	reservation, err := options.Budget.Reserve(limits)
+	if err != nil {
+		return limits, nil, err
+	}
+	return reservation.Limits(), reservation, nil
+}
+
+// stream forwards the messages of a running query until it ends, enforcing its limits,
+// charging its budget reservation, and settling its rate limit reservation, and closes the transport
+// afterwards. reservation and gate may be nil.
+func stream(ctx context.Context, transport *transport.SubprocessTransport, messageCh <-chan Message, limits budget.Limits, reservation *budget.Reservation, gate *rateGate) <-chan Message {
+	meter := budget.NewMeter()
+
 	// Wrap the channel to handle cleanup and type conversion
 	wrappedCh := make(chan Message, 10)
 	go func() {
 		defer close(wrappedCh)
-		defer transport.Disconnect()
+		// Closing the transport also stops a query that exceeded its budget
+		defer transport.Close()
 
-		for message := range messageCh {
+		var result *ResultMessage
+		// Charge before the channel is closed so callers observe the updated budget
+		defer func() {
+			costUSD, tokens := querySpend(meter, result)
+			reservation.Charge(costUSD, tokens)
+			gate.settle(tokens)
+		}()
+
+		send := func(message Message) bool {
 			select {
 			case wrappedCh <- message:
+				return true
 			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			// Warm processes read their output independently of ctx, so it is watched here too
+			var message Message
+			var ok bool
+			select {
+			case message, ok = <-messageCh:
+			case <-ctx.Done():
+			}
+			if !ok {
+				return
+			}
+			if msg, ok := message.(*ResultMessage); ok {
+				result = msg
+			}
+			gate.observe(message)
+			if !send(message) {
+				return
+			}
+			if err := checkLimits(limits, meter, message); err != nil {
+				send(NewErrorMessage(err))
 				return
 			}
 		}
 	}()
 
-	return wrappedCh, nil
+	return wrappedCh
+}
+
+// checkLimits records the usage of an assistant message and returns a *BudgetExceededError
+// once the query has exceeded its limits.
+func checkLimits(limits budget.Limits, meter *budget.Meter, message Message) error {
+	assistantMsg, ok := message.(*AssistantMessage)
+	if !ok || assistantMsg.Usage == nil {
+		return nil
+	}
+	meter.Record(assistantMsg.ID, assistantMsg.Model, *assistantMsg.Usage)
+	if limits.IsZero() {
+		return nil
+	}
+
+	costUSD, tokens := meter.CostUSD(), meter.Usage().TotalTokens()
+	if limits.Exceeded(costUSD, tokens) {
+		return NewBudgetExceededError(limits.MaxCostUSD, limits.MaxTokens, costUSD, tokens)
+	}
+	return nil
+}
+
+// querySpend returns the cost and tokens of a finished query. The totals reported by the
+// ResultMessage are preferred; queries stopped early are charged the estimate of the meter.
+func querySpend(meter *budget.Meter, result *ResultMessage) (costUSD float64, tokens int) {
+	costUSD, tokens = meter.CostUSD(), meter.Usage().TotalTokens()
+	if result == nil {
+		return costUSD, tokens
+	}
+	if result.TotalCostUSD != nil {
+		costUSD = *result.TotalCostUSD
+	}
+	if reported := usageTokens(result.Usage); reported > 0 {
+		tokens = reported
+	}
+	return costUSD, tokens
+}
+
+// usageTokens sums the token counts of a ResultMessage usage map.
+func usageTokens(usage map[string]any) int {
+	var tokens int
+	for _, key := range []string{"input_tokens", "output_tokens", "cache_creation_input_tokens", "cache_read_input_tokens"} {
+		if count, ok := usage[key].(float64); ok {
+			tokens += int(count)
+		}
+	}
+	return tokens
 }
 
 // Query is a convenience function that creates a default client and executes a query.
-// This is equivalent to calling NewClient(nil).Query(ctx, prompt, options).
+// This is equivalent to calling NewClient().Query(ctx, prompt, options).
 func Query(ctx context.Context, prompt string, options *QueryOptions) (<-chan Message, error) {
-	client := NewClient(nil)
+	client := NewClient()
 	return client.Query(ctx, prompt, options)
 }
+
+// QueryPrompt is a convenience function that creates a default client and executes a multimodal query.
+// This is equivalent to calling NewClient().QueryPrompt(ctx, prompt, options).
+func QueryPrompt(ctx context.Context, prompt *Prompt, options *QueryOptions) (<-chan Message, error) {
+	client := NewClient()
+	return client.QueryPrompt(ctx, prompt, options)
+}
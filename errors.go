@@ -17,8 +17,25 @@ type (
 	CLIJSONDecodeError = errors.CLIJSONDecodeError
 	// MessageParseError occurs when JSON from the CLI cannot be parsed into a Message struct.
 	MessageParseError = errors.MessageParseError
+	// CLIVersionError occurs when the CLI version cannot be detected or is older than MinimumCLIVersion.
+	CLIVersionError = errors.CLIVersionError
+	// ValidationError occurs when QueryOptions contain an invalid value or combination of values.
+	ValidationError = errors.ValidationError
+	// CheckpointError occurs when a workspace checkpoint cannot be created, restored, or discarded.
+	CheckpointError = errors.CheckpointError
+	// RollbackError occurs when a query result was rejected and the workspace was restored to its checkpoint.
+	RollbackError = errors.RollbackError
+	// WorktreeError occurs when a git worktree used to isolate a query cannot be created or removed.
+	WorktreeError = errors.WorktreeError
+	// BudgetExceededError occurs when a query is stopped because it exceeded MaxBudgetUSD or MaxTokens.
+	BudgetExceededError = errors.BudgetExceededError
+	// McpConfigError occurs when an MCP configuration file cannot be loaded or refers to unset environment variables.
+	McpConfigError = errors.McpConfigError
 )
 
+// ErrPoolQueueFull is returned when a query is rejected because the queue of a Pool is full.
+var ErrPoolQueueFull = errors.ErrPoolQueueFull
+
 // Re-export error constructor functions from internal package.
 // These functions create specific error types with appropriate context and details.
 var (
@@ -34,4 +51,18 @@ var (
 	NewCLIJSONDecodeError = errors.NewCLIJSONDecodeError
 	// NewMessageParseError creates a new message parse error with the raw JSON and underlying error.
 	NewMessageParseError = errors.NewMessageParseError
+	// NewCLIVersionError creates a new CLI version error with the detected and minimum versions.
+	NewCLIVersionError = errors.NewCLIVersionError
+	// NewValidationError creates a new validation error for the given field.
+	NewValidationError = errors.NewValidationError
+	// NewCheckpointError creates a new checkpoint error for the given directory.
+	NewCheckpointError = errors.NewCheckpointError
+	// NewRollbackError creates a new rollback error with the reason the result was rejected.
+	NewRollbackError = errors.NewRollbackError
+	// NewWorktreeError creates a new worktree error for the given repository and worktree path.
+	NewWorktreeError = errors.NewWorktreeError
+	// NewBudgetExceededError creates a new budget exceeded error with the limits and the spend so far.
+	NewBudgetExceededError = errors.NewBudgetExceededError
+	// NewMcpConfigError creates a new MCP configuration error for the given file.
+	NewMcpConfigError = errors.NewMcpConfigError
 )
@@ -122,3 +122,20 @@ func TestErrorChaining(t *testing.T) {
 		t.Errorf("Error string should contain 'connection error', got %q", errorStr)
 	}
 }
+
+func TestCLIVersionError(t *testing.T) {
+	err := NewCLIVersionError("CLI too old", "/usr/bin/claude", "0.9.0", MinimumCLIVersion, nil)
+
+	if err.CLIPath != "/usr/bin/claude" {
+		t.Errorf("CLIVersionError.CLIPath = %q, want %q", err.CLIPath, "/usr/bin/claude")
+	}
+	if err.Version != "0.9.0" {
+		t.Errorf("CLIVersionError.Version = %q, want %q", err.Version, "0.9.0")
+	}
+	if err.MinimumVersion != MinimumCLIVersion {
+		t.Errorf("CLIVersionError.MinimumVersion = %q, want %q", err.MinimumVersion, MinimumCLIVersion)
+	}
+	if !strings.Contains(err.Error(), "CLI too old") {
+		t.Errorf("CLIVersionError.Error() should contain message, got %q", err.Error())
+	}
+}
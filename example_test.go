@@ -27,6 +27,30 @@ func ExampleNewClient() {
 	// Client created with custom options
 }
 
+func ExampleNewClient_functionalOptions() {
+	// Configure a client with defaults shared by every query
+	client := claudecode.NewClient(
+		claudecode.WithCWD("/path/to/project"),
+		claudecode.WithModel("sonnet"),
+		claudecode.WithTools("Read", "Grep"),
+		claudecode.WithPermissionMode(claudecode.PermissionModeAcceptEdits),
+	)
+
+	// Per-query options are merged on top: tools are appended, scalars are replaced
+	options := claudecode.NewQueryOptions(
+		claudecode.WithTools("Bash"),
+		claudecode.WithMaxTurns(3),
+	)
+
+	messages, err := client.Query(context.Background(), "Run the test suite", options)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for message := range messages {
+		_ = message
+	}
+}
+
 func ExampleClient_Query() {
 	client := claudecode.NewClient(nil)
 	ctx := context.Background()
@@ -91,10 +115,12 @@ func ExampleQueryOptions() {
 		ContinueConversation: false,
 		MaxThinkingTokens:    1000,
 		CWD:                  "/workspace",
+		AddDirs:              []string{"/shared/docs"},
+		FallbackModel:        "haiku",
+		SettingSources:       []claudecode.SettingSource{claudecode.SettingSourceProject},
 		McpTools:             []string{"github", "jira"},
 		McpServers: map[string]claudecode.McpServerConfig{
-			"github": {
-				Type:    "stdio",
+			"github": claudecode.McpStdioServer{
 				Command: "mcp-github",
 				Args:    []string{"--token", "ghp_xxx"},
 			},
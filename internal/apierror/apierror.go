@@ -0,0 +1,49 @@
+// Package apierror parses the API errors the Claude Code CLI reports in its output.
+package apierror
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// pattern matches the errors the CLI reports for failed API requests, such as
+// `API Error: 529 {"type":"error","error":{"type":"overloaded_error",...}}`, at the start of a line.
+// The first group is the HTTP status, if any, and the rest of the line follows.
+var pattern = regexp.MustCompile(`(?im)^(?:error:\s*)?api error:?\s*\(?(\d{3})?\)?\s*(.*)$`)
+
+// Error is an API error reported by the CLI.
+type Error struct {
+	// Status is the HTTP status of the response, or empty if the CLI did not report one.
+	Status string
+	// Type is the type of the structured error in the response body, such as
+	// "overloaded_error", or empty if there is none.
+	Type string
+}
+
+// Find returns the API errors reported at the start of lines of output.
+func Find(output string) []Error {
+	var found []Error
+	for _, match := range pattern.FindAllStringSubmatch(output, -1) {
+		found = append(found, Error{Status: match[1], Type: errorType(match[2])})
+	}
+	return found
+}
+
+// errorType returns the type of the API error in the JSON body that follows an API error
+// status, or an empty string if there is none.
+func errorType(detail string) string {
+	start := strings.IndexByte(detail, '{')
+	if start < 0 {
+		return ""
+	}
+	var body struct {
+		Error struct {
+			Type string `json:"type"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(strings.NewReader(detail[start:])).Decode(&body); err != nil {
+		return ""
+	}
+	return body.Error.Type
+}
@@ -0,0 +1,51 @@
+package apierror
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Error
+	}{
+		{
+			name:   "structured",
+			output: `API Error: 529 {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			want:   []Error{{Status: "529", Type: "overloaded_error"}},
+		},
+		{
+			name:   "status only",
+			output: "Error: API Error: 429 Too Many Requests",
+			want:   []Error{{Status: "429"}},
+		},
+		{
+			name:   "without status",
+			output: "API Error (Request timed out.)",
+			want:   []Error{{}},
+		},
+		{
+			name:   "several lines",
+			output: "starting\nAPI Error: 500 {\"error\":{\"type\":\"api_error\"}}\nAPI Error: 401 Invalid API key",
+			want:   []Error{{Status: "500", Type: "api_error"}, {Status: "401"}},
+		},
+		{
+			name:   "not at line start",
+			output: "Wrote a handler for API Error: 429 responses",
+		},
+		{
+			name:   "unrelated",
+			output: "exit status 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Find(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
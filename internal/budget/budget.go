@@ -0,0 +1,278 @@
+// Package budget tracks token usage and estimated cost of queries and enforces spending limits.
+package budget
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+)
+
+// Usage contains token counts reported by the API for a message.
+type Usage struct {
+	// InputTokens is the number of uncached input tokens.
+	InputTokens int `json:"input_tokens"`
+	// OutputTokens is the number of generated tokens.
+	OutputTokens int `json:"output_tokens"`
+	// CacheCreationInputTokens is the number of input tokens written to the prompt cache.
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	// CacheReadInputTokens is the number of input tokens read from the prompt cache.
+	CacheReadInputTokens int `json:"cache_read_input_tokens,omitempty"`
+}
+
+// TotalTokens returns the number of tokens processed, including cached input tokens.
+func (u Usage) TotalTokens() int {
+	return u.InputTokens + u.OutputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+}
+
+// Add returns the sum of u and other.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		InputTokens:              u.InputTokens + other.InputTokens,
+		OutputTokens:             u.OutputTokens + other.OutputTokens,
+		CacheCreationInputTokens: u.CacheCreationInputTokens + other.CacheCreationInputTokens,
+		CacheReadInputTokens:     u.CacheReadInputTokens + other.CacheReadInputTokens,
+	}
+}
+
+// Pricing contains model prices in USD per million tokens.
+type Pricing struct {
+	Input      float64
+	Output     float64
+	CacheWrite float64
+	CacheRead  float64
+}
+
+// Cost returns the estimated cost of usage in USD.
+func (p Pricing) Cost(u Usage) float64 {
+	return (float64(u.InputTokens)*p.Input +
+		float64(u.OutputTokens)*p.Output +
+		float64(u.CacheCreationInputTokens)*p.CacheWrite +
+		float64(u.CacheReadInputTokens)*p.CacheRead) / 1e6
+}
+
+// modelPricing maps model name fragments to prices. Entries are matched in order,
+// so more specific fragments must come first.
+var modelPricing = []struct {
+	fragment string
+	pricing  Pricing
+}{
+	{"opus-4-5", Pricing{Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.5}},
+	{"opus", Pricing{Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.5}},
+	{"sonnet", Pricing{Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3}},
+	{"haiku-4-5", Pricing{Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.1}},
+	{"3-5-haiku", Pricing{Input: 0.8, Output: 4, CacheWrite: 1, CacheRead: 0.08}},
+	{"haiku", Pricing{Input: 0.25, Output: 1.25, CacheWrite: 0.3, CacheRead: 0.03}},
+}
+
+// PricingFor returns the estimated pricing of a model, such as "claude-sonnet-4-5-20250929".
+// Unknown models are priced like the most expensive model so that limits err on the safe side.
+func PricingFor(model string) Pricing {
+	model = strings.ToLower(model)
+	for _, entry := range modelPricing {
+		if strings.Contains(model, entry.fragment) {
+			return entry.pricing
+		}
+	}
+	return modelPricing[1].pricing
+}
+
+// Limits caps the usage of a query. Zero values mean no limit.
+type Limits struct {
+	// MaxCostUSD is the maximum estimated cost in USD.
+	MaxCostUSD float64
+	// MaxTokens is the maximum number of tokens, as counted by Usage.TotalTokens.
+	MaxTokens int
+}
+
+// IsZero reports whether no limit is set.
+func (l Limits) IsZero() bool {
+	return l.MaxCostUSD <= 0 && l.MaxTokens <= 0
+}
+
+// Exceeded reports whether the given spend exceeds the limits.
+func (l Limits) Exceeded(costUSD float64, tokens int) bool {
+	return (l.MaxCostUSD > 0 && costUSD > l.MaxCostUSD) || (l.MaxTokens > 0 && tokens > l.MaxTokens)
+}
+
+// Meter accumulates the usage of the assistant messages of a single query.
+// The CLI reports the usage of an API message with each of its content blocks, so
+// usage is recorded per message ID and only the latest report of each message counts.
+// A Meter is not safe for concurrent use.
+type Meter struct {
+	messages map[string]meterEntry
+	// anonymous accumulates usage reported without a message ID.
+	anonymous meterEntry
+}
+
+type meterEntry struct {
+	usage Usage
+	cost  float64
+}
+
+// NewMeter creates an empty Meter.
+func NewMeter() *Meter {
+	return &Meter{messages: make(map[string]meterEntry)}
+}
+
+// Record records the usage reported for an assistant message of the given model.
+func (m *Meter) Record(messageID string, model string, usage Usage) {
+	cost := PricingFor(model).Cost(usage)
+	if messageID == "" {
+		m.anonymous.usage = m.anonymous.usage.Add(usage)
+		m.anonymous.cost += cost
+		return
+	}
+	m.messages[messageID] = meterEntry{usage: usage, cost: cost}
+}
+
+// Usage returns the total usage recorded so far.
+func (m *Meter) Usage() Usage {
+	total := m.anonymous.usage
+	for _, entry := range m.messages {
+		total = total.Add(entry.usage)
+	}
+	return total
+}
+
+// CostUSD returns the estimated cost of the usage recorded so far.
+func (m *Meter) CostUSD() float64 {
+	total := m.anonymous.cost
+	for _, entry := range m.messages {
+		total += entry.cost
+	}
+	return total
+}
+
+// Budget is a spending limit shared by many queries, such as all queries of a tenant or job.
+// Each query reserves part of the budget when it starts and is charged when it ends, and
+// queries are refused once spending and the reservations of running queries reach a limit.
+//
+// A query reserves its own limits, capped by what is left. For a limit the query does not
+// set, it reserves half of what is left, so that it is stopped once it has spent that half
+// even if nothing else runs. Queries that may need more must set their own limits.
+//
+// It is safe for concurrent use.
+type Budget struct {
+	mu          sync.Mutex
+	limits      Limits
+	spentUSD    float64
+	tokens      int
+	reservedUSD float64
+	reserved    int
+}
+
+// NewBudget creates a Budget with the given limits. Zero limits mean no limit.
+func NewBudget(limits Limits) *Budget {
+	return &Budget{limits: limits}
+}
+
+// Limits returns the limits of the budget.
+func (b *Budget) Limits() Limits {
+	return b.limits
+}
+
+// Charge deducts the cost and tokens of a query from the budget.
+func (b *Budget) Charge(costUSD float64, tokens int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.spentUSD += costUSD
+	b.tokens += tokens
+}
+
+// Spent returns the cost and tokens charged so far.
+func (b *Budget) Spent() (costUSD float64, tokens int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spentUSD, b.tokens
+}
+
+// Depleted reports whether any limit of the budget has been reached by spending and the
+// reservations of running queries.
+func (b *Budget) Depleted() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.depleted()
+}
+
+// Reserve sets aside the part of the budget a new query may spend and returns it as a
+// Reservation, which must be charged when the query ends. The query is granted its own
+// limits, capped by what the budget has left; for a limit the query does not set, it is
+// granted half of what is left so that concurrent queries can still start.
+// It returns a *errors.BudgetExceededError if the budget is depleted.
+func (b *Budget) Reserve(limits Limits) (*Reservation, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.depleted() {
+		return nil, errors.NewBudgetExceededError(b.limits.MaxCostUSD, b.limits.MaxTokens,
+			b.spentUSD+b.reservedUSD, b.tokens+b.reserved)
+	}
+	if b.limits.MaxCostUSD > 0 {
+		remaining := b.limits.MaxCostUSD - b.spentUSD - b.reservedUSD
+		if limits.MaxCostUSD <= 0 {
+			limits.MaxCostUSD = remaining / 2
+		}
+		limits.MaxCostUSD = min(limits.MaxCostUSD, remaining)
+	}
+	if b.limits.MaxTokens > 0 {
+		remaining := b.limits.MaxTokens - b.tokens - b.reserved
+		if limits.MaxTokens <= 0 {
+			limits.MaxTokens = max(remaining/2, 1)
+		}
+		limits.MaxTokens = min(limits.MaxTokens, remaining)
+	}
+	r := &Reservation{budget: b, limits: limits}
+	if b.limits.MaxCostUSD > 0 {
+		r.costUSD = limits.MaxCostUSD
+	}
+	if b.limits.MaxTokens > 0 {
+		r.tokens = limits.MaxTokens
+	}
+	b.reservedUSD += r.costUSD
+	b.reserved += r.tokens
+	return r, nil
+}
+
+func (b *Budget) depleted() bool {
+	return (b.limits.MaxCostUSD > 0 && b.spentUSD+b.reservedUSD >= b.limits.MaxCostUSD) ||
+		(b.limits.MaxTokens > 0 && b.tokens+b.reserved >= b.limits.MaxTokens)
+}
+
+// Reservation is the part of a Budget set aside for a running query.
+type Reservation struct {
+	budget  *Budget
+	limits  Limits
+	costUSD float64
+	tokens  int
+	settled bool
+}
+
+// Limits returns the limits the query may spend.
+func (r *Reservation) Limits() Limits {
+	return r.limits
+}
+
+// Charge deducts what the query spent from the budget and returns the unused part of
+// the reservation to it. Only the first call of Charge or Release has an effect, and
+// neither has one on a nil Reservation.
+func (r *Reservation) Charge(costUSD float64, tokens int) {
+	if r == nil {
+		return
+	}
+	b := r.budget
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if r.settled {
+		return
+	}
+	r.settled = true
+	b.reservedUSD -= r.costUSD
+	b.reserved -= r.tokens
+	b.spentUSD += costUSD
+	b.tokens += tokens
+}
+
+// Release returns the whole reservation to the budget, for a query that never ran.
+func (r *Reservation) Release() {
+	r.Charge(0, 0)
+}
@@ -0,0 +1,188 @@
+package budget
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestPricingFor(t *testing.T) {
+	tests := []struct {
+		model string
+		want  Pricing
+	}{
+		{"claude-sonnet-4-5-20250929", Pricing{Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3}},
+		{"claude-opus-4-1-20250805", Pricing{Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.5}},
+		{"claude-opus-4-5", Pricing{Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.5}},
+		{"claude-haiku-4-5-20251001", Pricing{Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.1}},
+		{"claude-3-5-haiku-20241022", Pricing{Input: 0.8, Output: 4, CacheWrite: 1, CacheRead: 0.08}},
+		{"unknown-model", Pricing{Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.5}},
+	}
+	for _, tt := range tests {
+		if got := PricingFor(tt.model); got != tt.want {
+			t.Errorf("PricingFor(%q) = %+v, want %+v", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestMeter(t *testing.T) {
+	meter := NewMeter()
+	const model = "claude-sonnet-4-5"
+
+	// The same API message is reported once per content block
+	meter.Record("msg_1", model, Usage{InputTokens: 1000, OutputTokens: 10})
+	meter.Record("msg_1", model, Usage{InputTokens: 1000, OutputTokens: 500})
+	meter.Record("msg_2", model, Usage{InputTokens: 10, OutputTokens: 100, CacheReadInputTokens: 100000})
+	meter.Record("", model, Usage{OutputTokens: 400})
+
+	want := Usage{InputTokens: 1010, OutputTokens: 1000, CacheReadInputTokens: 100000}
+	if got := meter.Usage(); got != want {
+		t.Errorf("Usage() = %+v, want %+v", got, want)
+	}
+	if got := meter.Usage().TotalTokens(); got != 102010 {
+		t.Errorf("TotalTokens() = %d, want 102010", got)
+	}
+
+	// 1010 * $3 + 1000 * $15 + 100000 * $0.30 per million tokens
+	if got, want := meter.CostUSD(), 0.04803; math.Abs(got-want) > 1e-9 {
+		t.Errorf("CostUSD() = %v, want %v", got, want)
+	}
+}
+
+func TestLimitsExceeded(t *testing.T) {
+	tests := []struct {
+		limits Limits
+		cost   float64
+		tokens int
+		want   bool
+	}{
+		{Limits{}, 100, 1e9, false},
+		{Limits{MaxCostUSD: 1}, 1, 0, false},
+		{Limits{MaxCostUSD: 1}, 1.01, 0, true},
+		{Limits{MaxTokens: 100}, 0, 101, true},
+		{Limits{MaxCostUSD: 1, MaxTokens: 100}, 0.5, 50, false},
+	}
+	for _, tt := range tests {
+		if got := tt.limits.Exceeded(tt.cost, tt.tokens); got != tt.want {
+			t.Errorf("%+v.Exceeded(%v, %d) = %v, want %v", tt.limits, tt.cost, tt.tokens, got, tt.want)
+		}
+	}
+}
+
+func TestBudget(t *testing.T) {
+	b := NewBudget(Limits{MaxCostUSD: 10, MaxTokens: 1000})
+
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.Charge(0.05, 5)
+		}()
+	}
+	wg.Wait()
+
+	cost, tokens := b.Spent()
+	if math.Abs(cost-5) > 1e-9 || tokens != 500 {
+		t.Fatalf("Spent() = %v, %d; want 5, 500", cost, tokens)
+	}
+
+	// The remainder of the budget caps looser query limits
+	r, err := b.Reserve(Limits{MaxCostUSD: 8})
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if limits := r.Limits(); math.Abs(limits.MaxCostUSD-5) > 1e-9 || limits.MaxTokens != 250 {
+		t.Errorf("Reserve() limits = %+v, want remaining $5 and half of the remaining tokens", limits)
+	}
+	if !b.Depleted() {
+		t.Error("Depleted() = false while the remaining cost is reserved")
+	}
+	if _, err := b.Reserve(Limits{}); err == nil {
+		t.Error("Reserve() should fail while the remainder is reserved")
+	}
+	r.Charge(1, 100)
+	r.Charge(1, 100)
+	if cost, tokens := b.Spent(); math.Abs(cost-6) > 1e-9 || tokens != 600 {
+		t.Errorf("Spent() = %v, %d; want 6, 600 after charging a reservation once", cost, tokens)
+	}
+
+	r, err = b.Reserve(Limits{MaxCostUSD: 1})
+	if err != nil || r.Limits().MaxCostUSD != 1 {
+		t.Errorf("Reserve() = %+v, %v; want tighter query limit kept", r, err)
+	}
+	r.Release()
+
+	b.Charge(0, 400)
+	if !b.Depleted() {
+		t.Error("Depleted() = false after using every token")
+	}
+	if _, err := b.Reserve(Limits{}); err == nil {
+		t.Error("Reserve() on a depleted budget should fail")
+	}
+}
+
+func TestBudgetReserveUnsetLimits(t *testing.T) {
+	b := NewBudget(Limits{MaxCostUSD: 8, MaxTokens: 1000})
+	b.Charge(4, 200)
+
+	// A query without its own limits may spend half of what is left, however idle the budget is
+	r, err := b.Reserve(Limits{})
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if want := (Limits{MaxCostUSD: 2, MaxTokens: 400}); r.Limits() != want {
+		t.Errorf("Reserve() limits = %+v, want %+v", r.Limits(), want)
+	}
+
+	// The next one gets half of the rest
+	next, err := b.Reserve(Limits{MaxTokens: 100})
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if want := (Limits{MaxCostUSD: 1, MaxTokens: 100}); next.Limits() != want {
+		t.Errorf("Reserve() limits = %+v, want %+v", next.Limits(), want)
+	}
+
+	// Unused reservations are returned when the queries are charged
+	r.Charge(0.5, 50)
+	next.Release()
+	if cost, tokens := b.Spent(); math.Abs(cost-4.5) > 1e-9 || tokens != 250 {
+		t.Errorf("Spent() = %v, %d; want 4.5, 250", cost, tokens)
+	}
+	r, err = b.Reserve(Limits{})
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if want := (Limits{MaxCostUSD: 1.75, MaxTokens: 375}); r.Limits() != want {
+		t.Errorf("Reserve() limits = %+v, want %+v", r.Limits(), want)
+	}
+}
+
+func TestBudgetConcurrentReservations(t *testing.T) {
+	b := NewBudget(Limits{MaxCostUSD: 1, MaxTokens: 1000})
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var limits Limits
+			if i%2 == 0 {
+				limits = Limits{MaxCostUSD: 0.1, MaxTokens: 100}
+			}
+			r, err := b.Reserve(limits)
+			if err != nil {
+				return
+			}
+			// Every query spends all it may
+			r.Charge(r.Limits().MaxCostUSD, r.Limits().MaxTokens)
+		}()
+	}
+	wg.Wait()
+
+	cost, tokens := b.Spent()
+	if cost > 1+1e-9 || tokens > 1000 {
+		t.Errorf("Spent() = %v, %d; want at most the budget of $1 and 1000 tokens", cost, tokens)
+	}
+}
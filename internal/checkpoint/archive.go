@@ -0,0 +1,229 @@
+package checkpoint
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+)
+
+func createArchive(dir string) (*Checkpoint, error) {
+	file, err := os.CreateTemp("", "claude-checkpoint-*.tar.gz")
+	if err != nil {
+		return nil, errors.NewCheckpointError("failed to create checkpoint archive", dir, err)
+	}
+
+	err = writeArchive(file, dir)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return nil, errors.NewCheckpointError("failed to archive directory", dir, err)
+	}
+	return &Checkpoint{kind: KindArchive, dir: dir, archive: file.Name()}, nil
+}
+
+func writeArchive(w io.Writer, dir string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		var link string
+		switch {
+		case info.Mode().IsRegular(), info.IsDir():
+		case info.Mode()&fs.ModeSymlink != 0:
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		default:
+			// Sockets, devices, and pipes cannot be meaningfully restored
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// restoreArchive extracts the archive next to the directory and only then replaces the
+// contents of the directory, so a failed restore leaves the directory as it was.
+func (c *Checkpoint) restoreArchive() error {
+	if c.archive == "" {
+		return fmt.Errorf("checkpoint has been discarded")
+	}
+	staging, err := os.MkdirTemp(filepath.Dir(c.dir), "."+filepath.Base(c.dir)+".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	dirs, err := c.extractArchive(staging)
+	if err != nil {
+		return err
+	}
+	if err := swapContents(c.dir, staging); err != nil {
+		return err
+	}
+
+	// Apply directory modes deepest first, so restricted parents do not block their children.
+	// They are applied after the swap because moving a directory requires writing to it.
+	for _, header := range slices.Backward(dirs) {
+		path := filepath.Join(c.dir, filepath.FromSlash(header.Name))
+		if err := os.Chmod(path, header.FileInfo().Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractArchive extracts the archive into dir and returns the headers of its directories,
+// whose recorded modes have not been applied yet.
+func (c *Checkpoint) extractArchive(dir string) ([]*tar.Header, error) {
+	file, err := os.Open(c.archive)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+
+	var dirs []*tar.Header
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return dirs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !filepath.IsLocal(header.Name) {
+			return nil, fmt.Errorf("invalid path in checkpoint archive: %s", header.Name)
+		}
+		path := filepath.Join(dir, filepath.FromSlash(header.Name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			// Directories are created writable so their contents can be extracted
+			if err := os.MkdirAll(path, 0o700); err != nil {
+				return nil, err
+			}
+			dirs = append(dirs, header)
+		case tar.TypeSymlink:
+			if err := os.Symlink(header.Linkname, path); err != nil {
+				return nil, err
+			}
+		case tar.TypeReg:
+			if err := extractFile(tr, path, header.FileInfo().Mode().Perm()); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// swapContents replaces the entries of dir with those of staging, which must be on the same
+// file system. dir itself is kept so that processes working in it are not affected. The old
+// entries are moved aside first and moved back if the new ones cannot all be moved in.
+func swapContents(dir, staging string) error {
+	old, err := os.MkdirTemp(filepath.Dir(staging), filepath.Base(staging)+".old-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(old)
+
+	current, err := moveEntries(dir, old)
+	if err == nil {
+		var restored []string
+		if restored, err = moveEntries(staging, dir); err != nil {
+			for _, name := range restored {
+				os.RemoveAll(filepath.Join(dir, name))
+			}
+		}
+	}
+	if err != nil {
+		// Move back whatever was moved aside; the error that caused the rollback is reported
+		for _, name := range current {
+			os.Rename(filepath.Join(old, name), filepath.Join(dir, name))
+		}
+		return err
+	}
+	return nil
+}
+
+// moveEntries renames the entries of src into dst and returns the names of those moved,
+// including when it fails part way through.
+func moveEntries(src, dst string) ([]string, error) {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return nil, err
+	}
+	var moved []string
+	for _, entry := range entries {
+		if err := os.Rename(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return moved, err
+		}
+		moved = append(moved, entry.Name())
+	}
+	return moved, nil
+}
+
+func extractFile(r io.Reader, path string, perm fs.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
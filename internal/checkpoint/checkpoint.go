@@ -0,0 +1,216 @@
+// Package checkpoint snapshots a working directory so that changes made by the agent can be undone.
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+	"github.com/musaprg/claude-code-sdk-go/internal/git"
+)
+
+// Kind identifies how a checkpoint was taken.
+type Kind string
+
+// Checkpoint kind constants
+const (
+	// KindGit checkpoints record the HEAD, index, and working tree of a git repository
+	// as git objects. Files ignored by git are neither captured nor restored.
+	KindGit Kind = "git"
+	// KindArchive checkpoints copy the directory into a compressed tarball.
+	KindArchive Kind = "archive"
+)
+
+// Checkpoint is a snapshot of a directory that can be restored later.
+// A checkpoint is not safe for concurrent use and must not be restored while
+// the agent is still modifying the directory.
+type Checkpoint struct {
+	kind Kind
+	// dir is the directory that was snapshotted. For git checkpoints it is the repository root.
+	dir string
+
+	// Git checkpoint state
+	gitDir       string
+	headRef      string
+	head         string
+	indexTree    string
+	worktreeTree string
+
+	// Archive checkpoint state
+	archive string
+}
+
+// Create snapshots dir. If dir is inside a git repository, the whole repository is
+// checkpointed with git objects; otherwise dir is copied into a temporary archive.
+// Call Discard once the checkpoint is no longer needed.
+func Create(ctx context.Context, dir string) (*Checkpoint, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.NewCheckpointError("failed to resolve checkpoint directory", dir, err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		if err == nil {
+			err = fmt.Errorf("not a directory")
+		}
+		return nil, errors.NewCheckpointError("invalid checkpoint directory", dir, err)
+	}
+
+	if root, err := git.Run(ctx, dir, nil, "rev-parse", "--show-toplevel"); err == nil && root != "" {
+		return createGit(ctx, root)
+	}
+	return createArchive(dir)
+}
+
+// Kind reports how the checkpoint was taken.
+func (c *Checkpoint) Kind() Kind {
+	return c.kind
+}
+
+// Dir returns the directory covered by the checkpoint. For git checkpoints this
+// is the root of the repository, which may be a parent of the directory passed to Create.
+func (c *Checkpoint) Dir() string {
+	return c.dir
+}
+
+// Restore returns the directory to the state it had when the checkpoint was created.
+// Files created since then are removed, and modified or deleted files are restored.
+// For git checkpoints, the checked out branch, its commit, and the index are restored too.
+func (c *Checkpoint) Restore(ctx context.Context) error {
+	var err error
+	switch c.kind {
+	case KindGit:
+		err = c.restoreGit(ctx)
+	case KindArchive:
+		err = c.restoreArchive()
+	default:
+		err = fmt.Errorf("unknown checkpoint kind %q", c.kind)
+	}
+	if err != nil {
+		return errors.NewCheckpointError("failed to restore checkpoint", c.dir, err)
+	}
+	return nil
+}
+
+// Discard releases the resources held by the checkpoint, such as its archive file.
+// The checkpoint cannot be restored afterwards.
+func (c *Checkpoint) Discard() error {
+	if c.archive == "" {
+		return nil
+	}
+	err := os.Remove(c.archive)
+	c.archive = ""
+	if err != nil && !os.IsNotExist(err) {
+		return errors.NewCheckpointError("failed to discard checkpoint", c.dir, err)
+	}
+	return nil
+}
+
+func createGit(ctx context.Context, root string) (*Checkpoint, error) {
+	c := &Checkpoint{kind: KindGit, dir: root}
+
+	gitDir, err := git.Run(ctx, root, nil, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return nil, errors.NewCheckpointError("failed to locate git directory", root, err)
+	}
+	c.gitDir = gitDir
+
+	// Both may be empty: HEAD is detached, or the branch has no commits yet
+	c.headRef, _ = git.Run(ctx, root, nil, "symbolic-ref", "-q", "HEAD")
+	c.head, _ = git.Run(ctx, root, nil, "rev-parse", "--verify", "-q", "HEAD")
+
+	if c.indexTree, err = git.Run(ctx, root, nil, "write-tree"); err != nil {
+		return nil, errors.NewCheckpointError("failed to record git index", root, err)
+	}
+
+	// Record the working tree through a scratch index so the real index is left untouched
+	err = c.withScratchIndex(func(env []string) error {
+		if _, err := git.Run(ctx, root, env, "add", "-A"); err != nil {
+			return err
+		}
+		c.worktreeTree, err = git.Run(ctx, root, env, "write-tree")
+		return err
+	})
+	if err != nil {
+		return nil, errors.NewCheckpointError("failed to record git working tree", root, err)
+	}
+	return c, nil
+}
+
+func (c *Checkpoint) restoreGit(ctx context.Context) error {
+	if err := c.restoreHead(ctx); err != nil {
+		return err
+	}
+
+	err := c.withScratchIndex(func(env []string) error {
+		if _, err := git.Run(ctx, c.dir, env, "read-tree", c.worktreeTree); err != nil {
+			return err
+		}
+		// Remove files that did not exist at checkpoint time, then restore the rest
+		if _, err := git.Run(ctx, c.dir, env, "clean", "-f", "-d", "-q"); err != nil {
+			return err
+		}
+		_, err := git.Run(ctx, c.dir, env, "checkout-index", "-a", "-f")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = git.Run(ctx, c.dir, nil, "read-tree", c.indexTree)
+	return err
+}
+
+// restoreHead checks out the branch recorded in the checkpoint again and moves it
+// back to the recorded commit, dropping any commits made since.
+func (c *Checkpoint) restoreHead(ctx context.Context) error {
+	switch {
+	case c.headRef != "" && c.head != "":
+		if _, err := git.Run(ctx, c.dir, nil, "symbolic-ref", "HEAD", c.headRef); err != nil {
+			return err
+		}
+		_, err := git.Run(ctx, c.dir, nil, "update-ref", c.headRef, c.head)
+		return err
+	case c.headRef != "":
+		// The branch had no commits yet
+		if _, err := git.Run(ctx, c.dir, nil, "symbolic-ref", "HEAD", c.headRef); err != nil {
+			return err
+		}
+		_, err := git.Run(ctx, c.dir, nil, "update-ref", "-d", c.headRef)
+		return err
+	default:
+		_, err := git.Run(ctx, c.dir, nil, "update-ref", "--no-deref", "HEAD", c.head)
+		return err
+	}
+}
+
+// withScratchIndex runs fn with an environment pointing git at a temporary index file
+// seeded from the repository index, which lets git reuse its cached file stats.
+func (c *Checkpoint) withScratchIndex(fn func(env []string) error) error {
+	scratch, err := os.CreateTemp("", "claude-checkpoint-index-*")
+	if err != nil {
+		return err
+	}
+	scratchPath := scratch.Name()
+	defer os.Remove(scratchPath)
+
+	index, err := os.ReadFile(filepath.Join(c.gitDir, "index"))
+	if err == nil {
+		_, err = scratch.Write(index)
+	} else if os.IsNotExist(err) {
+		err = nil
+	}
+	if closeErr := scratch.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if len(index) == 0 {
+		// git rejects an empty index file, but accepts a missing one
+		os.Remove(scratchPath)
+	}
+
+	return fn([]string{"GIT_INDEX_FILE=" + scratchPath})
+}
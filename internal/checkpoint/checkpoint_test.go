@@ -0,0 +1,198 @@
+package checkpoint
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/git"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertFile(t *testing.T, path string, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("ReadFile(%s) error = %v", path, err)
+		return
+	}
+	if string(got) != want {
+		t.Errorf("%s = %q, want %q", path, got, want)
+	}
+}
+
+func assertMissing(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("%s should not exist after restore (err = %v)", path, err)
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := git.Run(context.Background(), dir, []string{
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	}, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// modify simulates an agent run: edits, creations, and deletions.
+func modify(t *testing.T, dir string) {
+	t.Helper()
+	writeFile(t, filepath.Join(dir, "tracked.txt"), "modified")
+	writeFile(t, filepath.Join(dir, "new", "created.txt"), "created")
+	if err := os.Remove(filepath.Join(dir, "deleted.txt")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGitCheckpoint(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	writeFile(t, filepath.Join(dir, "tracked.txt"), "original")
+	writeFile(t, filepath.Join(dir, "deleted.txt"), "keep me")
+	writeFile(t, filepath.Join(dir, ".gitignore"), "ignored.txt\n")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "initial")
+	head := runGit(t, dir, "rev-parse", "HEAD")
+
+	// Uncommitted state that must survive the round trip
+	writeFile(t, filepath.Join(dir, "staged.txt"), "staged")
+	runGit(t, dir, "add", "staged.txt")
+	writeFile(t, filepath.Join(dir, "untracked.txt"), "untracked")
+	writeFile(t, filepath.Join(dir, "ignored.txt"), "ignored")
+
+	cp, err := Create(ctx, filepath.Join(dir, "."))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer cp.Discard()
+	if cp.Kind() != KindGit {
+		t.Fatalf("Kind() = %q, want %q", cp.Kind(), KindGit)
+	}
+
+	modify(t, dir)
+	writeFile(t, filepath.Join(dir, "untracked.txt"), "changed")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "agent commit")
+	runGit(t, dir, "checkout", "-q", "-b", "agent-branch")
+
+	if err := cp.Restore(ctx); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	assertFile(t, filepath.Join(dir, "tracked.txt"), "original")
+	assertFile(t, filepath.Join(dir, "deleted.txt"), "keep me")
+	assertFile(t, filepath.Join(dir, "untracked.txt"), "untracked")
+	assertFile(t, filepath.Join(dir, "ignored.txt"), "ignored")
+	assertMissing(t, filepath.Join(dir, "new"))
+
+	if got := runGit(t, dir, "symbolic-ref", "--short", "HEAD"); got != "main" {
+		t.Errorf("checked out branch = %q, want main", got)
+	}
+	if got := runGit(t, dir, "rev-parse", "HEAD"); got != head {
+		t.Errorf("HEAD = %s, want %s", got, head)
+	}
+	if got := runGit(t, dir, "status", "--porcelain"); got != "A  staged.txt\n?? untracked.txt" {
+		t.Errorf("git status = %q, want staged and untracked files only", got)
+	}
+}
+
+func TestArchiveCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "tracked.txt"), "original")
+	writeFile(t, filepath.Join(dir, "deleted.txt"), "keep me")
+	writeFile(t, filepath.Join(dir, "sub", "nested.txt"), "nested")
+	if err := os.Symlink("tracked.txt", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	cp, err := Create(ctx, dir)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if cp.Kind() != KindArchive {
+		t.Fatalf("Kind() = %q, want %q", cp.Kind(), KindArchive)
+	}
+
+	modify(t, dir)
+	if err := os.RemoveAll(filepath.Join(dir, "sub")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cp.Restore(ctx); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	assertFile(t, filepath.Join(dir, "tracked.txt"), "original")
+	assertFile(t, filepath.Join(dir, "deleted.txt"), "keep me")
+	assertFile(t, filepath.Join(dir, "sub", "nested.txt"), "nested")
+	assertFile(t, filepath.Join(dir, "link"), "original")
+	assertMissing(t, filepath.Join(dir, "new"))
+
+	if err := cp.Discard(); err != nil {
+		t.Fatalf("Discard() error = %v", err)
+	}
+	if err := cp.Restore(ctx); err == nil {
+		t.Error("Restore() after Discard() should fail")
+	}
+}
+
+func TestArchiveCheckpointFailedRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "tracked.txt"), "original")
+	writeFile(t, filepath.Join(dir, "deleted.txt"), "keep me")
+	writeFile(t, filepath.Join(dir, "large.txt"), strings.Repeat("checkpoint data ", 1<<14))
+
+	cp, err := Create(ctx, dir)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer cp.Discard()
+	modify(t, dir)
+
+	// A truncated archive fails part way through extraction
+	info, err := os.Stat(cp.archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(cp.archive, info.Size()/2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cp.Restore(ctx); err == nil {
+		t.Fatal("Restore() of a truncated archive should fail")
+	}
+	assertFile(t, filepath.Join(dir, "tracked.txt"), "modified")
+	assertFile(t, filepath.Join(dir, "new", "created.txt"), "created")
+	assertMissing(t, filepath.Join(dir, "deleted.txt"))
+
+	siblings, err := os.ReadDir(filepath.Dir(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(siblings) != 1 {
+		t.Errorf("Restore() left temporary directories next to %s: %v", dir, siblings)
+	}
+}
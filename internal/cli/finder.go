@@ -5,32 +5,50 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/musaprg/claude-code-sdk-go/internal/errors"
 )
 
+// CLIPathEnv is the environment variable that overrides CLI discovery.
+// When set, FindCLI uses its value instead of searching PATH and standard locations.
+const CLIPathEnv = "CLAUDE_CODE_CLI_PATH"
+
 // FindCLI finds the Claude Code CLI binary
 func FindCLI() (string, error) {
-	// First check if "claude" is in PATH
-	if cli, err := exec.LookPath("claude"); err == nil {
-		return cli, nil
+	// An explicit override always wins, even if it points at a missing file
+	if override := os.Getenv(CLIPathEnv); override != "" {
+		if fileExists(override) {
+			return override, nil
+		}
+		err := errors.NewCLINotFoundError(override,
+			fmt.Errorf("%s is set but does not point to a file", CLIPathEnv))
+		err.SearchedPaths = []string{override}
+		return "", err
 	}
 
 	// Common installation locations
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return "", errors.NewCLINotFoundError("", err)
+		notFound := errors.NewCLINotFoundError("", err)
+		notFound.SearchedPaths = []string{"$PATH/claude"}
+		return "", notFound
 	}
 
-	locations := []string{
-		filepath.Join(homeDir, ".npm-global", "bin", "claude"),
-		"/usr/local/bin/claude",
-		filepath.Join(homeDir, ".local", "bin", "claude"),
-		filepath.Join(homeDir, "node_modules", ".bin", "claude"),
-		filepath.Join(homeDir, ".yarn", "bin", "claude"),
+	return findCLI(SearchPaths(homeDir))
+}
+
+// findCLI looks for "claude" in PATH and then in locations, in order.
+func findCLI(locations []string) (string, error) {
+	// First check if "claude" is in PATH
+	searched := []string{"$PATH/claude"}
+	if cli, err := exec.LookPath("claude"); err == nil {
+		return cli, nil
 	}
 
 	for _, path := range locations {
+		searched = append(searched, path)
 		if fileExists(path) {
 			return path, nil
 		}
@@ -38,20 +56,140 @@ func FindCLI() (string, error) {
 
 	// Check if Node.js is available
 	if _, err := exec.LookPath("node"); err != nil {
-		return "", errors.NewCLINotFoundError("",
+		notFound := errors.NewCLINotFoundError("",
 			fmt.Errorf("Claude Code requires Node.js, which is not installed.\n\n"+
 				"Install Node.js from: https://nodejs.org/\n\n"+
 				"After installing Node.js, install Claude Code:\n"+
-				"  npm install -g @anthropic-ai/claude-code"))
+				"  npm install -g @anthropic-ai/claude-code\n\n"+
+				"%s", formatSearchedPaths(searched)))
+		notFound.SearchedPaths = searched
+		return "", notFound
 	}
 
-	return "", errors.NewCLINotFoundError("",
+	notFound := errors.NewCLINotFoundError("",
 		fmt.Errorf("Claude Code not found. Install with:\n"+
 			"  npm install -g @anthropic-ai/claude-code\n\n"+
 			"If already installed locally, try:\n"+
 			"  export PATH=\"$HOME/node_modules/.bin:$PATH\"\n\n"+
 			"Or specify the path when creating transport:\n"+
-			"  NewClient(&ClientOptions{CLIPath: \"/path/to/claude\"})"))
+			"  NewClient(&ClientOptions{CLIPath: \"/path/to/claude\"})\n"+
+			"  or set %s=/path/to/claude\n\n"+
+			"%s", CLIPathEnv, formatSearchedPaths(searched)))
+	notFound.SearchedPaths = searched
+	return "", notFound
+}
+
+// SearchPaths returns the standard installation locations checked by FindCLI after PATH,
+// in search order. Version manager directories (nvm, fnm) are expanded to every installed
+// Node.js version, newest first.
+func SearchPaths(homeDir string) []string {
+	locations := []string{
+		filepath.Join(homeDir, ".claude", "local", "claude"),
+		filepath.Join(homeDir, ".npm-global", "bin", "claude"),
+		"/usr/local/bin/claude",
+		filepath.Join(homeDir, ".local", "bin", "claude"),
+		filepath.Join(homeDir, "node_modules", ".bin", "claude"),
+		filepath.Join(homeDir, ".yarn", "bin", "claude"),
+		// Homebrew
+		"/opt/homebrew/bin/claude",
+		"/home/linuxbrew/.linuxbrew/bin/claude",
+		// Volta and Bun
+		filepath.Join(homeDir, ".volta", "bin", "claude"),
+		filepath.Join(homeDir, ".bun", "bin", "claude"),
+	}
+
+	// pnpm
+	if pnpmHome := os.Getenv("PNPM_HOME"); pnpmHome != "" {
+		locations = append(locations, filepath.Join(pnpmHome, "claude"))
+	}
+	locations = append(locations,
+		filepath.Join(homeDir, ".local", "share", "pnpm", "claude"),
+		filepath.Join(homeDir, "Library", "pnpm", "claude"))
+
+	// nvm
+	nvmDir := os.Getenv("NVM_DIR")
+	if nvmDir == "" {
+		nvmDir = filepath.Join(homeDir, ".nvm")
+	}
+	locations = append(locations,
+		globNewestFirst(filepath.Join(nvmDir, "versions", "node", "*", "bin", "claude"))...)
+
+	// fnm
+	fnmDirs := []string{
+		filepath.Join(homeDir, ".local", "share", "fnm"),
+		filepath.Join(homeDir, ".fnm"),
+		filepath.Join(homeDir, "Library", "Application Support", "fnm"),
+	}
+	if fnmDir := os.Getenv("FNM_DIR"); fnmDir != "" {
+		fnmDirs = append([]string{fnmDir}, fnmDirs...)
+	}
+	for _, dir := range fnmDirs {
+		locations = append(locations,
+			globNewestFirst(filepath.Join(dir, "node-versions", "*", "installation", "bin", "claude"))...)
+	}
+
+	return locations
+}
+
+// IsScript reports whether path is a JavaScript entry point (such as a local cli.js)
+// that must be run through a Node.js binary rather than executed directly.
+func IsScript(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".js", ".mjs", ".cjs":
+		return true
+	default:
+		return false
+	}
+}
+
+// Command returns the executable and leading arguments used to run the CLI at path.
+// JavaScript entry points are run through nodePath, or through "node" from PATH if nodePath is empty.
+func Command(path string, nodePath string) (string, []string, error) {
+	if !IsScript(path) {
+		return path, nil, nil
+	}
+
+	if nodePath == "" {
+		var err error
+		nodePath, err = exec.LookPath("node")
+		if err != nil {
+			notFound := errors.NewCLINotFoundError(path,
+				fmt.Errorf("running %s requires Node.js, which was not found in PATH", path))
+			notFound.SearchedPaths = []string{"$PATH/node"}
+			return "", nil, notFound
+		}
+	}
+	return nodePath, []string{path}, nil
+}
+
+func formatSearchedPaths(paths []string) string {
+	return "Searched locations:\n  " + strings.Join(paths, "\n  ")
+}
+
+// globNewestFirst returns the matches of pattern, whose wildcard stands for version directories
+// such as "v20.11.1", newest version first. Matches without a version follow in reverse lexical order.
+func globNewestFirst(pattern string) []string {
+	matches, _ := filepath.Glob(pattern)
+	prefix := pattern[:strings.Index(pattern, "*")]
+	version := func(path string) (SemVer, bool) {
+		v, err := ParseVersion(strings.TrimPrefix(path, prefix))
+		return v, err == nil
+	}
+	slices.SortFunc(matches, func(a, b string) int {
+		versionA, okA := version(a)
+		versionB, okB := version(b)
+		switch {
+		case okA && !okB:
+			return -1
+		case !okA && okB:
+			return 1
+		case okA && okB && versionA != versionB:
+			return versionB.Compare(versionA)
+		default:
+			return strings.Compare(b, a)
+		}
+	})
+	return matches
 }
 
 func fileExists(path string) bool {
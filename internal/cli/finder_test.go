@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+)
+
+func TestFindCLISearchOrder(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first", "claude")
+	second := filepath.Join(dir, "second", "claude")
+	if err := os.MkdirAll(filepath.Dir(second), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := findCLI([]string{first, second})
+	if err != nil {
+		t.Fatalf("findCLI() error = %v", err)
+	}
+	if got != second {
+		t.Errorf("findCLI() = %q, want %q", got, second)
+	}
+}
+
+func TestGlobNewestFirst(t *testing.T) {
+	dir := t.TempDir()
+	for _, version := range []string{"v9.11.2", "v18.20.4", "v10.0.0", "system", "v18.3.0"} {
+		path := filepath.Join(dir, version, "bin", "claude")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	for _, path := range globNewestFirst(filepath.Join(dir, "*", "bin", "claude")) {
+		rel, _ := filepath.Rel(dir, path)
+		got = append(got, strings.Split(rel, string(filepath.Separator))[0])
+	}
+	if want := []string{"v18.20.4", "v18.3.0", "v10.0.0", "v9.11.2", "system"}; !slices.Equal(got, want) {
+		t.Errorf("globNewestFirst() = %v, want %v", got, want)
+	}
+}
+
+func TestFindCLINotFound(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	dir := t.TempDir()
+	locations := []string{filepath.Join(dir, "a", "claude"), filepath.Join(dir, "b", "claude")}
+
+	_, err := findCLI(locations)
+	notFound, ok := err.(*errors.CLINotFoundError)
+	if !ok {
+		t.Fatalf("findCLI() error = %v, want *errors.CLINotFoundError", err)
+	}
+
+	want := append([]string{"$PATH/claude"}, locations...)
+	if !slices.Equal(notFound.SearchedPaths, want) {
+		t.Errorf("CLINotFoundError.SearchedPaths = %v, want %v", notFound.SearchedPaths, want)
+	}
+	for _, path := range locations {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("CLINotFoundError.Error() should list %q, got %q", path, err.Error())
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	name, args, err := Command("/usr/bin/claude", "/opt/node")
+	if err != nil || name != "/usr/bin/claude" || len(args) != 0 {
+		t.Errorf("Command(binary) = %q, %v, %v; want the binary itself", name, args, err)
+	}
+
+	name, args, err = Command("/srv/claude/cli.js", "/opt/node")
+	if err != nil || name != "/opt/node" || !slices.Equal(args, []string{"/srv/claude/cli.js"}) {
+		t.Errorf("Command(cli.js) = %q, %v, %v; want node with the script as first argument", name, args, err)
+	}
+}
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+)
+
+const (
+	// MinimumCLIVersion is the oldest Claude Code CLI release supported by this SDK: the first
+	// release that accepts every flag the SDK passes without consulting Features. The newest of
+	// them is --settings, added in 1.0.61; --input-format stream-json and --add-dir are older.
+	MinimumCLIVersion = "1.0.61"
+
+	versionTimeout = 10 * time.Second
+)
+
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)\.(\d+)`)
+
+// SemVer is a semantic version of the Claude Code CLI.
+type SemVer struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseVersion extracts the first "major.minor.patch" version from s,
+// such as the output of "claude --version" ("1.0.88 (Claude Code)").
+func ParseVersion(s string) (SemVer, error) {
+	match := versionPattern.FindStringSubmatch(s)
+	if match == nil {
+		return SemVer{}, fmt.Errorf("no version number found in %q", strings.TrimSpace(s))
+	}
+	var v SemVer
+	v.Major, _ = strconv.Atoi(match[1])
+	v.Minor, _ = strconv.Atoi(match[2])
+	v.Patch, _ = strconv.Atoi(match[3])
+	return v, nil
+}
+
+// MustParseVersion is like ParseVersion but panics if s does not contain a version.
+func MustParseVersion(s string) SemVer {
+	v, err := ParseVersion(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (v SemVer) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0, or +1 depending on whether v is older than, equal to, or newer than other.
+func (v SemVer) Compare(other SemVer) int {
+	switch {
+	case v.Major != other.Major:
+		return compareInts(v.Major, other.Major)
+	case v.Minor != other.Minor:
+		return compareInts(v.Minor, other.Minor)
+	default:
+		return compareInts(v.Patch, other.Patch)
+	}
+}
+
+// AtLeast reports whether v is the same as or newer than other.
+func (v SemVer) AtLeast(other SemVer) bool {
+	return v.Compare(other) >= 0
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Features describes which optional CLI capabilities are available in a given CLI version.
+// The transport consults it to degrade gracefully on older CLIs instead of passing unknown flags.
+type Features struct {
+	// SystemPromptFile indicates support for --system-prompt-file and --append-system-prompt-file.
+	SystemPromptFile bool
+	// PartialMessages indicates support for --include-partial-messages.
+	PartialMessages bool
+	// SettingSources indicates support for --setting-sources.
+	SettingSources bool
+	// Agents indicates support for --agents.
+	Agents bool
+}
+
+// Minimum CLI versions for each optional feature.
+const (
+	SystemPromptFileVersion = "1.0.51"
+	PartialMessagesVersion  = "1.0.86"
+	SettingSourcesVersion   = "2.0.0"
+	AgentsVersion           = "2.0.0"
+)
+
+// AllFeatures returns a Features value with every capability enabled.
+// It is used when version detection is skipped.
+func AllFeatures() Features {
+	return Features{
+		SystemPromptFile: true,
+		PartialMessages:  true,
+		SettingSources:   true,
+		Agents:           true,
+	}
+}
+
+// FeaturesFor returns the features supported by CLI version v.
+func FeaturesFor(v SemVer) Features {
+	return Features{
+		SystemPromptFile: v.AtLeast(MustParseVersion(SystemPromptFileVersion)),
+		PartialMessages:  v.AtLeast(MustParseVersion(PartialMessagesVersion)),
+		SettingSources:   v.AtLeast(MustParseVersion(SettingSourcesVersion)),
+		Agents:           v.AtLeast(MustParseVersion(AgentsVersion)),
+	}
+}
+
+// versionCacheKey identifies a CLI binary. The modification time and size are included
+// so that upgrading the CLI in place invalidates the cached version.
+type versionCacheKey struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+var versionCache sync.Map // map[versionCacheKey]SemVer
+
+// Version runs "claude --version" for the CLI at path and returns the reported version.
+// Results are cached per binary, so repeated calls are cheap.
+// JavaScript entry points are run through "node" from PATH.
+func Version(path string) (SemVer, error) {
+	return detectVersion(path, "")
+}
+
+func detectVersion(path string, nodePath string) (SemVer, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return SemVer{}, errors.NewCLIVersionError(
+			fmt.Sprintf("failed to detect Claude Code CLI version at %s", path), path, "", MinimumCLIVersion, err)
+	}
+	key := versionCacheKey{path: path, modTime: info.ModTime(), size: info.Size()}
+	if cached, ok := versionCache.Load(key); ok {
+		return cached.(SemVer), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+
+	name, args, err := Command(path, nodePath)
+	if err != nil {
+		return SemVer{}, err
+	}
+	output, err := exec.CommandContext(ctx, name, append(args, "--version")...).Output()
+	if err != nil {
+		return SemVer{}, errors.NewCLIVersionError(
+			fmt.Sprintf("failed to detect Claude Code CLI version at %s", path), path, "", MinimumCLIVersion, err)
+	}
+
+	v, err := ParseVersion(string(output))
+	if err != nil {
+		return SemVer{}, errors.NewCLIVersionError(
+			fmt.Sprintf("failed to detect Claude Code CLI version at %s", path), path, "", MinimumCLIVersion, err)
+	}
+
+	versionCache.Store(key, v)
+	return v, nil
+}
+
+// CheckVersion detects the version of the CLI at path and verifies that it is at least MinimumCLIVersion.
+// nodePath is used to run JavaScript entry points and may be empty to use "node" from PATH.
+func CheckVersion(path string, nodePath string) (SemVer, error) {
+	v, err := detectVersion(path, nodePath)
+	if err != nil {
+		return SemVer{}, err
+	}
+	if !v.AtLeast(MustParseVersion(MinimumCLIVersion)) {
+		return SemVer{}, errors.NewCLIVersionError(
+			fmt.Sprintf("Claude Code CLI version %s is older than the minimum supported version %s",
+				v, MinimumCLIVersion),
+			path, v.String(), MinimumCLIVersion, nil)
+	}
+	return v, nil
+}
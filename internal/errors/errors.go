@@ -30,6 +30,9 @@ func NewClaudeSDKError(message string, cause error) *ClaudeSDKError {
 	}
 }
 
+// ErrPoolQueueFull is returned when a query is rejected because the queue of a pool is full.
+var ErrPoolQueueFull = NewClaudeSDKError("pool queue is full", nil)
+
 // CLIConnectionError represents errors that occur when establishing or maintaining
 // communication with the Claude Code CLI process, such as pipe creation failures
 // or working directory access issues.
@@ -50,11 +53,17 @@ type CLINotFoundError struct {
 	*ClaudeSDKError
 	// CLIPath contains the path where the CLI was expected to be found.
 	CLIPath string
+	// SearchedPaths lists every location checked during CLI discovery, in search order.
+	SearchedPaths []string
 }
 
 // NewCLINotFoundError creates a new CLI not found error with the path that was searched.
+// If cliPath is empty, the error describes a failed search of the standard locations.
 func NewCLINotFoundError(cliPath string, cause error) *CLINotFoundError {
-	message := fmt.Sprintf("Claude Code CLI not found at path: %s", cliPath)
+	message := "Claude Code CLI not found"
+	if cliPath != "" {
+		message = fmt.Sprintf("Claude Code CLI not found at path: %s", cliPath)
+	}
 	return &CLINotFoundError{
 		ClaudeSDKError: NewClaudeSDKError(message, cause),
 		CLIPath:        cliPath,
@@ -111,3 +120,144 @@ func NewMessageParseError(message string, rawData any, cause error) *MessagePars
 		RawData:        rawData,
 	}
 }
+
+// CLIVersionError represents an error when the installed Claude Code CLI version
+// cannot be determined or is older than the minimum version supported by the SDK.
+type CLIVersionError struct {
+	*ClaudeSDKError
+	// CLIPath contains the path of the CLI that was checked.
+	CLIPath string
+	// Version contains the detected CLI version, or is empty if it could not be determined.
+	Version string
+	// MinimumVersion contains the oldest CLI version supported by the SDK.
+	MinimumVersion string
+}
+
+// NewCLIVersionError creates a new CLI version error with the detected and minimum versions.
+func NewCLIVersionError(message string, cliPath string, version string, minimumVersion string, cause error) *CLIVersionError {
+	return &CLIVersionError{
+		ClaudeSDKError: NewClaudeSDKError(message, cause),
+		CLIPath:        cliPath,
+		Version:        version,
+		MinimumVersion: minimumVersion,
+	}
+}
+
+// ValidationError represents an invalid configuration value detected before the CLI is started.
+// Multiple validation errors are combined with errors.Join; use errors.As to inspect them.
+type ValidationError struct {
+	*ClaudeSDKError
+	// Field is the path of the invalid field, such as "QueryOptions.Resume" or
+	// `QueryOptions.McpServers["github"].Command`.
+	Field string
+}
+
+// NewValidationError creates a new validation error for the given field.
+func NewValidationError(field string, message string) *ValidationError {
+	return &ValidationError{
+		ClaudeSDKError: NewClaudeSDKError(fmt.Sprintf("%s: %s", field, message), nil),
+		Field:          field,
+	}
+}
+
+// CheckpointError represents a failure to create, restore, or discard a workspace checkpoint.
+type CheckpointError struct {
+	*ClaudeSDKError
+	// Dir is the directory covered by the checkpoint.
+	Dir string
+}
+
+// NewCheckpointError creates a new checkpoint error for the given directory.
+func NewCheckpointError(message string, dir string, cause error) *CheckpointError {
+	return &CheckpointError{
+		ClaudeSDKError: NewClaudeSDKError(message, cause),
+		Dir:            dir,
+	}
+}
+
+// RollbackError is returned when a query's result was rejected and the workspace was
+// restored to the checkpoint taken before the query. The cause is the reason for the rejection.
+type RollbackError struct {
+	*ClaudeSDKError
+	// Dir is the directory that was restored.
+	Dir string
+}
+
+// NewRollbackError creates a new rollback error with the reason the result was rejected.
+func NewRollbackError(dir string, reason error) *RollbackError {
+	return &RollbackError{
+		ClaudeSDKError: NewClaudeSDKError("query rejected; workspace restored to checkpoint", reason),
+		Dir:            dir,
+	}
+}
+
+// WorktreeError represents a failure to create, inspect, or remove a git worktree used to isolate a query.
+type WorktreeError struct {
+	*ClaudeSDKError
+	// Repo is the repository the worktree belongs to.
+	Repo string
+	// Path is the directory of the worktree, if it was created.
+	Path string
+}
+
+// NewWorktreeError creates a new worktree error for the given repository and worktree path.
+func NewWorktreeError(message string, repo string, path string, cause error) *WorktreeError {
+	return &WorktreeError{
+		ClaudeSDKError: NewClaudeSDKError(message, cause),
+		Repo:           repo,
+		Path:           path,
+	}
+}
+
+// BudgetExceededError is reported when a query is stopped because its usage exceeded
+// the configured cost or token limit. Cost is estimated from per-message usage.
+type BudgetExceededError struct {
+	*ClaudeSDKError
+	// MaxCostUSD is the cost limit in USD, or zero if unlimited.
+	MaxCostUSD float64
+	// MaxTokens is the token limit, or zero if unlimited.
+	MaxTokens int
+	// CostUSD is the estimated cost spent when the query was stopped.
+	CostUSD float64
+	// Tokens is the number of tokens used when the query was stopped.
+	Tokens int
+}
+
+// NewBudgetExceededError creates a new budget exceeded error with the limits and the spend so far.
+func NewBudgetExceededError(maxCostUSD float64, maxTokens int, costUSD float64, tokens int) *BudgetExceededError {
+	message := fmt.Sprintf("budget exceeded: spent $%.4f and %d tokens", costUSD, tokens)
+	switch {
+	case maxCostUSD > 0 && maxTokens > 0:
+		message += fmt.Sprintf(" (limits $%.4f, %d tokens)", maxCostUSD, maxTokens)
+	case maxCostUSD > 0:
+		message += fmt.Sprintf(" (limit $%.4f)", maxCostUSD)
+	case maxTokens > 0:
+		message += fmt.Sprintf(" (limit %d tokens)", maxTokens)
+	}
+	return &BudgetExceededError{
+		ClaudeSDKError: NewClaudeSDKError(message, nil),
+		MaxCostUSD:     maxCostUSD,
+		MaxTokens:      maxTokens,
+		CostUSD:        costUSD,
+		Tokens:         tokens,
+	}
+}
+
+// McpConfigError represents an MCP configuration file that cannot be read or parsed, or
+// that refers to environment variables that are not set.
+type McpConfigError struct {
+	*ClaudeSDKError
+	// Path is the configuration file, or empty if the configuration was not read from a file.
+	Path string
+}
+
+// NewMcpConfigError creates a new MCP configuration error for the given file.
+func NewMcpConfigError(message string, path string, cause error) *McpConfigError {
+	if path != "" {
+		message = fmt.Sprintf("%s: %s", path, message)
+	}
+	return &McpConfigError{
+		ClaudeSDKError: NewClaudeSDKError(message, cause),
+		Path:           path,
+	}
+}
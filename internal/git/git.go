@@ -0,0 +1,35 @@
+// Package git runs git commands on behalf of the workspace helpers.
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Run runs git in dir with env appended to the process environment and returns
+// its trimmed standard output. Failures include git's standard error output.
+func Run(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	out, err := Output(ctx, dir, env, args...)
+	return strings.TrimSpace(out), err
+}
+
+// Output is like Run but returns standard output unmodified, as needed for patches,
+// whose trailing whitespace is significant.
+func Output(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
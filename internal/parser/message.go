@@ -28,6 +28,8 @@ func ParseMessage(data map[string]any) (types.Message, error) {
 		return parseSystemMessage(data)
 	case "result":
 		return parseResultMessage(data)
+	case "stream_event":
+		return parseStreamEvent(data)
 	default:
 		return nil, errors.NewMessageParseError(
 			fmt.Sprintf("Unknown message type: %s", messageType), data, nil)
@@ -44,18 +46,31 @@ func parseUserMessage(data map[string]any) (*types.UserMessage, error) {
 
 		// Try array content (tool result messages)
 		if contentArray, ok := message["content"].([]any); ok {
-			// For tool result messages, extract the content from the first tool_result block
+			userMsg := types.NewUserMessage("")
 			for _, blockData := range contentArray {
-				if blockMap, ok := blockData.(map[string]any); ok {
-					if blockType, ok := blockMap["type"].(string); ok && blockType == "tool_result" {
-						if toolContent, ok := blockMap["content"].(string); ok {
-							return types.NewUserMessage(toolContent), nil
-						}
+				blockMap, ok := blockData.(map[string]any)
+				if !ok {
+					continue
+				}
+				// Skip blocks that are not meaningful in a response stream (e.g., echoed images)
+				block, err := parseContentBlock(blockMap)
+				if err != nil {
+					continue
+				}
+				userMsg.ContentBlocks = append(userMsg.ContentBlocks, block)
+
+				// For tool result messages, extract the content from the first tool_result block
+				if toolResult, ok := block.(*types.ToolResultBlock); ok && userMsg.Content == "" {
+					if toolContent, ok := toolResult.Content.(string); ok {
+						userMsg.Content = toolContent
 					}
 				}
 			}
 			// If we can't extract tool result content, use a placeholder
-			return types.NewUserMessage("[Tool result message]"), nil
+			if userMsg.Content == "" {
+				userMsg.Content = "[Tool result message]"
+			}
+			return userMsg, nil
 		}
 	}
 
@@ -70,9 +85,11 @@ func parseUserMessage(data map[string]any) (*types.UserMessage, error) {
 
 func parseAssistantMessage(data map[string]any) (*types.AssistantMessage, error) {
 	var contentData []any
+	var apiMessage map[string]any
 
 	// Try the nested format first: data["message"]["content"]
 	if message, ok := data["message"].(map[string]any); ok {
+		apiMessage = message
 		if content, ok := message["content"].([]any); ok {
 			contentData = content
 		}
@@ -104,7 +121,31 @@ func parseAssistantMessage(data map[string]any) (*types.AssistantMessage, error)
 		contentBlocks = append(contentBlocks, block)
 	}
 
-	return types.NewAssistantMessage(contentBlocks), nil
+	assistantMsg := types.NewAssistantMessage(contentBlocks)
+
+	// Optional API message metadata, only present in the nested format
+	if apiMessage != nil {
+		if id, ok := apiMessage["id"].(string); ok {
+			assistantMsg.ID = id
+		}
+		if model, ok := apiMessage["model"].(string); ok {
+			assistantMsg.Model = model
+		}
+		if usage, ok := apiMessage["usage"].(map[string]any); ok {
+			assistantMsg.Usage = parseUsage(usage)
+		}
+	}
+
+	return assistantMsg, nil
+}
+
+func parseUsage(data map[string]any) *types.Usage {
+	usage := &types.Usage{}
+	usage.InputTokens, _ = getIntField(data, "input_tokens")
+	usage.OutputTokens, _ = getIntField(data, "output_tokens")
+	usage.CacheCreationInputTokens, _ = getIntField(data, "cache_creation_input_tokens")
+	usage.CacheReadInputTokens, _ = getIntField(data, "cache_read_input_tokens")
+	return usage
 }
 
 func parseContentBlock(blockData map[string]any) (types.ContentBlock, error) {
@@ -220,6 +261,31 @@ func parseResultMessage(data map[string]any) (*types.ResultMessage, error) {
 	return message, nil
 }
 
+func parseStreamEvent(data map[string]any) (*types.StreamEvent, error) {
+	uuid, ok := data["uuid"].(string)
+	if !ok {
+		return nil, errors.NewMessageParseError("Missing required field 'uuid' in stream event", data, nil)
+	}
+
+	sessionID, ok := data["session_id"].(string)
+	if !ok {
+		return nil, errors.NewMessageParseError("Missing required field 'session_id' in stream event", data, nil)
+	}
+
+	event, ok := data["event"].(map[string]any)
+	if !ok {
+		return nil, errors.NewMessageParseError("Missing required field 'event' in stream event", data, nil)
+	}
+
+	message := types.NewStreamEvent(uuid, sessionID, event)
+
+	if parentToolUseID, ok := data["parent_tool_use_id"].(string); ok {
+		message.ParentToolUseID = &parentToolUseID
+	}
+
+	return message, nil
+}
+
 func getIntField(data map[string]any, field string) (int, bool) {
 	val, exists := data[field]
 	if !exists {
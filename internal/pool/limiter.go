@@ -0,0 +1,193 @@
+// Package pool limits how many queries run concurrently, queueing the rest.
+package pool
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+)
+
+// Config configures a Limiter. Zero values mean no limit.
+type Config struct {
+	// MaxConcurrent is the maximum number of slots held at once.
+	MaxConcurrent int
+	// MaxPerKey is the maximum number of slots held at once for the same key.
+	MaxPerKey int
+	// KeyLimits overrides MaxPerKey for individual keys.
+	KeyLimits map[string]int
+	// MaxQueued is the maximum number of waiting requests. Further requests are rejected.
+	MaxQueued int
+}
+
+// Stats is a snapshot of a Limiter's activity.
+type Stats struct {
+	// Active is the number of slots currently held.
+	Active int
+	// Queued is the number of requests currently waiting for a slot.
+	Queued int
+	// Acquired is the total number of slots granted.
+	Acquired uint64
+	// Canceled is the total number of requests whose context ended while they were queued.
+	Canceled uint64
+	// Rejected is the total number of requests rejected because the queue was full.
+	Rejected uint64
+	// TotalWait is the total time granted requests spent queued.
+	TotalWait time.Duration
+	// MaxWait is the longest time a granted request spent queued.
+	MaxWait time.Duration
+}
+
+// AverageWait returns the average time granted requests spent queued.
+func (s Stats) AverageWait() time.Duration {
+	if s.Acquired == 0 {
+		return 0
+	}
+	return s.TotalWait / time.Duration(s.Acquired)
+}
+
+type waiter struct {
+	key      string
+	priority int
+	seq      uint64
+	enqueued time.Time
+	// granted is closed once the waiter holds a slot.
+	granted chan struct{}
+}
+
+// Limiter hands out slots to requests, queueing requests that exceed the global or
+// per-key limits. Queued requests are granted by descending priority, then in FIFO order;
+// a request blocked only by its key's limit does not hold up requests for other keys.
+// It is safe for concurrent use.
+type Limiter struct {
+	mu     sync.Mutex
+	config Config
+	active int
+	perKey map[string]int
+	// queue is sorted by descending priority, then ascending seq.
+	queue []*waiter
+	seq   uint64
+	stats Stats
+}
+
+// NewLimiter creates a Limiter with the given configuration.
+func NewLimiter(config Config) *Limiter {
+	return &Limiter{config: config, perKey: make(map[string]int)}
+}
+
+// Acquire blocks until a slot for key is available or ctx ends, and returns a function
+// that releases the slot. The release function must be called exactly once.
+func (l *Limiter) Acquire(ctx context.Context, key string, priority int) (func(), error) {
+	l.mu.Lock()
+	if len(l.queue) == 0 && l.available(key) {
+		l.grant(key, 0)
+		l.mu.Unlock()
+		return l.releaseFunc(key), nil
+	}
+	if l.config.MaxQueued > 0 && len(l.queue) >= l.config.MaxQueued {
+		l.stats.Rejected++
+		l.mu.Unlock()
+		return nil, errors.ErrPoolQueueFull
+	}
+
+	w := &waiter{key: key, priority: priority, seq: l.seq, enqueued: time.Now(), granted: make(chan struct{})}
+	l.seq++
+	i, _ := slices.BinarySearchFunc(l.queue, w, compareWaiters)
+	l.queue = slices.Insert(l.queue, i, w)
+	// A request for an idle key may be grantable right away even though others are queued
+	l.dispatch()
+	l.mu.Unlock()
+
+	select {
+	case <-w.granted:
+		return l.releaseFunc(key), nil
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-w.granted:
+		// Granted concurrently with the cancellation; hand the slot to the next request
+		l.release(key)
+	default:
+		l.queue = slices.DeleteFunc(l.queue, func(q *waiter) bool { return q == w })
+		l.stats.Canceled++
+	}
+	return nil, errors.NewClaudeSDKError("gave up waiting for a pool slot", ctx.Err())
+}
+
+// Stats returns a snapshot of the limiter's activity.
+func (l *Limiter) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := l.stats
+	stats.Active = l.active
+	stats.Queued = len(l.queue)
+	return stats
+}
+
+func (l *Limiter) releaseFunc(key string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.release(key)
+		})
+	}
+}
+
+func (l *Limiter) release(key string) {
+	l.active--
+	if l.perKey[key]--; l.perKey[key] == 0 {
+		delete(l.perKey, key)
+	}
+	l.dispatch()
+}
+
+// dispatch grants slots to queued requests in queue order while capacity remains.
+func (l *Limiter) dispatch() {
+	for i := 0; i < len(l.queue); {
+		if l.config.MaxConcurrent > 0 && l.active >= l.config.MaxConcurrent {
+			return
+		}
+		w := l.queue[i]
+		if !l.available(w.key) {
+			i++
+			continue
+		}
+		l.queue = slices.Delete(l.queue, i, i+1)
+		l.grant(w.key, time.Since(w.enqueued))
+		close(w.granted)
+	}
+}
+
+func (l *Limiter) available(key string) bool {
+	if l.config.MaxConcurrent > 0 && l.active >= l.config.MaxConcurrent {
+		return false
+	}
+	limit := l.config.MaxPerKey
+	if keyLimit, ok := l.config.KeyLimits[key]; ok {
+		limit = keyLimit
+	}
+	return limit <= 0 || l.perKey[key] < limit
+}
+
+func (l *Limiter) grant(key string, wait time.Duration) {
+	l.active++
+	l.perKey[key]++
+	l.stats.Acquired++
+	l.stats.TotalWait += wait
+	l.stats.MaxWait = max(l.stats.MaxWait, wait)
+}
+
+func compareWaiters(a, b *waiter) int {
+	if a.priority != b.priority {
+		return cmp.Compare(b.priority, a.priority)
+	}
+	return cmp.Compare(a.seq, b.seq)
+}
@@ -0,0 +1,132 @@
+package pool
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+)
+
+// acquireAsync starts an Acquire call and reports its order of completion on order.
+func acquireAsync(t *testing.T, l *Limiter, key string, priority int, name string, order chan<- string, releases chan<- func()) {
+	t.Helper()
+	go func() {
+		release, err := l.Acquire(context.Background(), key, priority)
+		if err != nil {
+			t.Errorf("Acquire(%s) error = %v", name, err)
+			return
+		}
+		order <- name
+		releases <- release
+	}()
+}
+
+// waitQueued waits until n requests are queued.
+func waitQueued(t *testing.T, l *Limiter, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for l.Stats().Queued != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Stats().Queued = %d, want %d", l.Stats().Queued, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLimiterPriority(t *testing.T) {
+	l := NewLimiter(Config{MaxConcurrent: 1})
+	ctx := context.Background()
+
+	release, err := l.Acquire(ctx, "", 0)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	order := make(chan string, 3)
+	releases := make(chan func(), 3)
+	acquireAsync(t, l, "", 0, "low-1", order, releases)
+	waitQueued(t, l, 1)
+	acquireAsync(t, l, "", 0, "low-2", order, releases)
+	waitQueued(t, l, 2)
+	acquireAsync(t, l, "", 10, "high", order, releases)
+	waitQueued(t, l, 3)
+
+	release()
+	for _, want := range []string{"high", "low-1", "low-2"} {
+		if got := <-order; got != want {
+			t.Errorf("granted %s, want %s", got, want)
+		}
+		(<-releases)()
+	}
+
+	stats := l.Stats()
+	if stats.Active != 0 || stats.Queued != 0 || stats.Acquired != 4 || stats.MaxWait <= 0 {
+		t.Errorf("Stats() = %+v, want 4 acquired with recorded wait", stats)
+	}
+}
+
+func TestLimiterPerKey(t *testing.T) {
+	l := NewLimiter(Config{MaxConcurrent: 3, MaxPerKey: 1, KeyLimits: map[string]int{"wide": 2}})
+	ctx := context.Background()
+
+	if _, err := l.Acquire(ctx, "repo-a", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	order := make(chan string, 3)
+	releases := make(chan func(), 3)
+	// repo-a is at its limit, but must not block other keys queued behind it
+	acquireAsync(t, l, "repo-a", 0, "repo-a", order, releases)
+	waitQueued(t, l, 1)
+	acquireAsync(t, l, "wide", 0, "wide-1", order, releases)
+	acquireAsync(t, l, "wide", 0, "wide-2", order, releases)
+
+	got := map[string]bool{<-order: true, <-order: true}
+	if !got["wide-1"] || !got["wide-2"] {
+		t.Errorf("granted %v, want both wide requests", got)
+	}
+	if stats := l.Stats(); stats.Active != 3 || stats.Queued != 1 {
+		t.Errorf("Stats() = %+v, want 3 active and repo-a queued", stats)
+	}
+}
+
+func TestLimiterCancel(t *testing.T) {
+	l := NewLimiter(Config{MaxConcurrent: 1, MaxQueued: 1})
+	release, err := l.Acquire(context.Background(), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := l.Acquire(ctx, "", 0); err == nil {
+			t.Error("Acquire() should fail once its context expires")
+		}
+	}()
+	waitQueued(t, l, 1)
+
+	// The queue is full
+	if _, err := l.Acquire(context.Background(), "", 0); !stderrors.Is(err, errors.ErrPoolQueueFull) {
+		t.Errorf("Acquire() with a full queue error = %v, want ErrPoolQueueFull", err)
+	}
+	wg.Wait()
+
+	stats := l.Stats()
+	if stats.Queued != 0 || stats.Canceled != 1 || stats.Rejected != 1 {
+		t.Errorf("Stats() = %+v, want 1 canceled and 1 rejected", stats)
+	}
+
+	// Releasing twice must not free two slots
+	release()
+	release()
+	if stats := l.Stats(); stats.Active != 0 {
+		t.Errorf("Stats().Active = %d after release, want 0", stats.Active)
+	}
+}
@@ -0,0 +1,235 @@
+// Package ratelimit paces query starts per model with token buckets for requests and tokens per minute.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+)
+
+// DefaultCooldown is how long a model is paused after a rate limit error that did not say
+// when to retry.
+const DefaultCooldown = 30 * time.Second
+
+// Limit is the rate limit of a model. Zero values mean no limit.
+type Limit struct {
+	// RequestsPerMinute is the maximum number of queries started per minute.
+	RequestsPerMinute int
+	// TokensPerMinute is the maximum number of tokens used per minute, as counted by Usage.TotalTokens.
+	TokensPerMinute int
+	// EstimatedTokens is the number of tokens reserved when a query starts. The reservation is
+	// corrected once the query ends. If zero, the average of the model's finished queries is used.
+	EstimatedTokens int
+}
+
+// Config configures a Limiter.
+type Config struct {
+	// Default is the limit of models not listed in Models. Each model has its own buckets.
+	Default Limit
+	// Models overrides Default for individual models, keyed by QueryOptions.Model.
+	// The key "" applies to queries that use the CLI's default model.
+	Models map[string]Limit
+	// Cooldown is how long a model is paused after a rate limit error without a retry-after hint.
+	// Defaults to DefaultCooldown.
+	Cooldown time.Duration
+}
+
+// bucket is a token bucket that refills continuously up to its capacity. Its level may
+// go negative when more tokens were used than reserved, delaying later reservations.
+type bucket struct {
+	capacity float64
+	// rate is the refill rate in tokens per second.
+	rate    float64
+	level   float64
+	updated time.Time
+}
+
+func newBucket(perMinute int, now time.Time) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &bucket{capacity: float64(perMinute), rate: float64(perMinute) / 60, level: float64(perMinute), updated: now}
+}
+
+func (b *bucket) refill(now time.Time) {
+	b.level = min(b.capacity, b.level+now.Sub(b.updated).Seconds()*b.rate)
+	b.updated = now
+}
+
+// delay returns how long until n tokens are available. Requests larger than the
+// bucket only wait for it to be full.
+func (b *bucket) delay(n float64) time.Duration {
+	n = min(n, b.capacity)
+	if b.level >= n {
+		return 0
+	}
+	return time.Duration(math.Ceil((n - b.level) / b.rate * float64(time.Second)))
+}
+
+type model struct {
+	limit    Limit
+	requests *bucket
+	tokens   *bucket
+	paused   time.Time
+	// finished and totalTokens estimate the tokens of a query when Limit.EstimatedTokens is unset.
+	finished    int
+	totalTokens int
+}
+
+func (m *model) estimate() int {
+	if m.limit.EstimatedTokens > 0 {
+		return m.limit.EstimatedTokens
+	}
+	if m.finished == 0 {
+		return 0
+	}
+	return m.totalTokens / m.finished
+}
+
+// Limiter paces the start of queries so that each model stays within its rate limit.
+// It is safe for concurrent use, and is meant to be shared by every client using the same API key.
+type Limiter struct {
+	mu     sync.Mutex
+	config Config
+	models map[string]*model
+	now    func() time.Time
+}
+
+// NewLimiter creates a Limiter with the given configuration.
+func NewLimiter(config Config) *Limiter {
+	if config.Cooldown <= 0 {
+		config.Cooldown = DefaultCooldown
+	}
+	return &Limiter{config: config, models: make(map[string]*model), now: time.Now}
+}
+
+// Reservation is the capacity taken by a started query.
+type Reservation struct {
+	limiter *Limiter
+	model   string
+	tokens  int
+	once    sync.Once
+}
+
+// Wait blocks until the model can start a query, then reserves one request and the estimated
+// tokens of a query. The reservation must be settled with the tokens the query actually used,
+// or cancelled if the query could not be started.
+func (l *Limiter) Wait(ctx context.Context, modelName string) (*Reservation, error) {
+	for {
+		l.mu.Lock()
+		now := l.now()
+		m := l.model(modelName, now)
+		estimate := m.estimate()
+
+		delay := m.paused.Sub(now)
+		if m.requests != nil {
+			m.requests.refill(now)
+			delay = max(delay, m.requests.delay(1))
+		}
+		if m.tokens != nil {
+			m.tokens.refill(now)
+			delay = max(delay, m.tokens.delay(float64(estimate)))
+		}
+		if delay <= 0 {
+			if m.requests != nil {
+				m.requests.level--
+			}
+			if m.tokens != nil {
+				m.tokens.level -= float64(estimate)
+			}
+			l.mu.Unlock()
+			return &Reservation{limiter: l, model: modelName, tokens: estimate}, nil
+		}
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.NewClaudeSDKError("gave up waiting for the rate limit of model "+displayName(modelName), ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// Settle corrects the reservation with the tokens the query actually used.
+// Only the first call of Settle or Cancel has an effect.
+func (r *Reservation) Settle(tokens int) {
+	r.once.Do(func() {
+		l := r.limiter
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		now := l.now()
+		m := l.model(r.model, now)
+		if m.tokens != nil {
+			m.tokens.refill(now)
+			m.tokens.level -= float64(tokens - r.tokens)
+		}
+		m.finished++
+		m.totalTokens += tokens
+	})
+}
+
+// Cancel returns the reserved request and tokens, for a query that could not be started.
+// Unlike settling, it does not count the query towards the model's average tokens per query.
+// Only the first call of Settle or Cancel has an effect.
+func (r *Reservation) Cancel() {
+	r.once.Do(func() {
+		l := r.limiter
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		now := l.now()
+		m := l.model(r.model, now)
+		if m.requests != nil {
+			m.requests.refill(now)
+			m.requests.level = min(m.requests.capacity, m.requests.level+1)
+		}
+		if m.tokens != nil {
+			m.tokens.refill(now)
+			m.tokens.level = min(m.tokens.capacity, m.tokens.level+float64(r.tokens))
+		}
+	})
+}
+
+// Pause stops the model from starting queries for d, after the API reported that its rate
+// limit was hit. A non-positive d pauses for the configured cooldown.
+func (l *Limiter) Pause(modelName string, d time.Duration) {
+	if d <= 0 {
+		d = l.config.Cooldown
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	m := l.model(modelName, now)
+	if until := now.Add(d); until.After(m.paused) {
+		m.paused = until
+	}
+}
+
+// model returns the state of a model, creating it on first use. The caller must hold l.mu.
+func (l *Limiter) model(name string, now time.Time) *model {
+	m, ok := l.models[name]
+	if !ok {
+		limit, ok := l.config.Models[name]
+		if !ok {
+			limit = l.config.Default
+		}
+		m = &model{
+			limit:    limit,
+			requests: newBucket(limit.RequestsPerMinute, now),
+			tokens:   newBucket(limit.TokensPerMinute, now),
+		}
+		l.models[name] = m
+	}
+	return m
+}
+
+func displayName(model string) string {
+	if model == "" {
+		return "(default)"
+	}
+	return model
+}
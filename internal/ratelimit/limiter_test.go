@@ -0,0 +1,193 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock replaces the limiter's clock so that tests control bucket refills.
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestLimiter(config Config) (*Limiter, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	l := NewLimiter(config)
+	l.now = func() time.Time { return clock.now }
+	return l, clock
+}
+
+// tryWait reports whether Wait succeeds without blocking for long.
+func tryWait(t *testing.T, l *Limiter, model string) *Reservation {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	reservation, err := l.Wait(ctx, model)
+	if err != nil {
+		return nil
+	}
+	return reservation
+}
+
+func TestLimiterRequests(t *testing.T) {
+	l, clock := newTestLimiter(Config{
+		Default: Limit{RequestsPerMinute: 2},
+		Models:  map[string]Limit{"unlimited": {}},
+	})
+
+	for i := range 2 {
+		if tryWait(t, l, "sonnet") == nil {
+			t.Fatalf("request %d was not admitted", i+1)
+		}
+	}
+	if tryWait(t, l, "sonnet") != nil {
+		t.Fatal("third request within a minute was admitted")
+	}
+	if tryWait(t, l, "opus") == nil {
+		t.Error("a different model shares the bucket")
+	}
+	for range 5 {
+		if tryWait(t, l, "unlimited") == nil {
+			t.Fatal("a model without limits was throttled")
+		}
+	}
+
+	clock.advance(30 * time.Second)
+	if tryWait(t, l, "sonnet") == nil {
+		t.Error("request was not admitted after the bucket refilled")
+	}
+}
+
+func TestLimiterTokens(t *testing.T) {
+	l, clock := newTestLimiter(Config{Default: Limit{TokensPerMinute: 1000}})
+
+	// Without finished queries nothing is reserved up front
+	reservation := tryWait(t, l, "")
+	if reservation == nil {
+		t.Fatal("first query was not admitted")
+	}
+	reservation.Settle(1500)
+	reservation.Settle(1500) // only the first call counts
+
+	clock.advance(30 * time.Second)
+	if tryWait(t, l, "") != nil {
+		t.Fatal("query was admitted while the bucket was in debt")
+	}
+
+	// The bucket is back at 0, but the average query needs 1500 tokens, capped at the capacity of 1000
+	clock.advance(30 * time.Second)
+	if tryWait(t, l, "") != nil {
+		t.Fatal("query was admitted before its estimated tokens were available")
+	}
+	clock.advance(60 * time.Second)
+	if tryWait(t, l, "") == nil {
+		t.Error("query was not admitted after the bucket refilled")
+	}
+}
+
+func TestLimiterCancel(t *testing.T) {
+	l, _ := newTestLimiter(Config{Default: Limit{RequestsPerMinute: 2, TokensPerMinute: 2000}})
+
+	reservation := tryWait(t, l, "")
+	if reservation == nil {
+		t.Fatal("first query was not admitted")
+	}
+	reservation.Settle(600)
+
+	// A query that fails to start gives back its request and tokens
+	for i := range 3 {
+		reservation := tryWait(t, l, "")
+		if reservation == nil {
+			t.Fatalf("query %d was not admitted after earlier ones were cancelled", i+1)
+		}
+		reservation.Cancel()
+		reservation.Settle(5000) // only the first call counts
+	}
+
+	// ...and does not lower the estimate of later queries
+	if m := l.models[""]; m.finished != 1 || m.estimate() != 600 {
+		t.Errorf("finished = %d, estimate = %d; want only the settled query counted", m.finished, m.estimate())
+	}
+}
+
+func TestLimiterPause(t *testing.T) {
+	l, clock := newTestLimiter(Config{Cooldown: 5 * time.Second})
+
+	l.Pause("sonnet", 10*time.Second)
+	l.Pause("sonnet", time.Second) // a shorter pause does not shorten the current one
+	if tryWait(t, l, "sonnet") != nil {
+		t.Fatal("paused model was admitted")
+	}
+	clock.advance(11 * time.Second)
+	if tryWait(t, l, "sonnet") == nil {
+		t.Fatal("model was not admitted after its pause")
+	}
+
+	l.Pause("sonnet", 0)
+	clock.advance(4 * time.Second)
+	if tryWait(t, l, "sonnet") != nil {
+		t.Fatal("model was admitted during the cooldown")
+	}
+	clock.advance(time.Second)
+	if tryWait(t, l, "sonnet") == nil {
+		t.Error("model was not admitted after the cooldown")
+	}
+}
+
+func TestFromText(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    time.Duration
+		limited bool
+	}{
+		{text: `API Error: 429 {"type":"error","error":{"type":"rate_limit_error","message":"Rate limited"}}`, limited: true},
+		{text: "API Error: 429 Too Many Requests, retry-after: 20", want: 20 * time.Second, limited: true},
+		{text: "Error: API Error: 429 Rate limit reached. Retry after 1.5s", want: 1500 * time.Millisecond, limited: true},
+		{text: `API Error: {"error":{"type":"rate_limit_error"},"retry_after_ms":500}`, want: 500 * time.Millisecond, limited: true},
+		{text: "Starting\nAPI Error: 429 Too Many Requests", limited: true},
+		{text: `API Error: 529 {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`},
+		{text: `API Error: 429 {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`},
+		{text: "Invalid API key"},
+		// Mentions of rate limits outside of an API error are not rate limit errors
+		{text: "Added a rate limit middleware that returns 429 Too Many Requests"},
+		{text: "Processed 1429 files"},
+		{text: "Read config/rate_limit.yaml"},
+		{text: "The handler logs: API Error: 429"},
+	}
+
+	for _, tt := range tests {
+		got, limited := FromText(tt.text)
+		if got != tt.want || limited != tt.limited {
+			t.Errorf("FromText(%q) = (%v, %v), want (%v, %v)", tt.text, got, limited, tt.want, tt.limited)
+		}
+	}
+}
+
+func TestFromEvent(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tests := []struct {
+		name    string
+		subtype string
+		data    map[string]any
+		want    time.Duration
+		limited bool
+	}{
+		{name: "retry after ms", subtype: "rate_limit", data: map[string]any{"retry_after_ms": 2500.0}, want: 2500 * time.Millisecond, limited: true},
+		{name: "retry after seconds", subtype: "api_retry", data: map[string]any{"status": 429.0, "retry_after": 3.0}, want: 3 * time.Second, limited: true},
+		{name: "resets at", subtype: "rate_limit", data: map[string]any{"resets_at": float64(now.Unix() + 60)}, want: time.Minute, limited: true},
+		{name: "already reset", subtype: "rate_limit", data: map[string]any{"resets_at": now.Add(-time.Second).Format(time.RFC3339)}},
+		{name: "error text", subtype: "api_retry", data: map[string]any{"error": "API Error: 429 Too Many Requests"}, limited: true},
+		{name: "unrelated error text", subtype: "api_retry", data: map[string]any{"error": "Processed 1429 files"}},
+		{name: "unrelated", subtype: "init", data: map[string]any{"session_id": "s1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, limited := FromEvent(tt.subtype, tt.data, now)
+			if got != tt.want || limited != tt.limited {
+				t.Errorf("FromEvent() = (%v, %v), want (%v, %v)", got, limited, tt.want, tt.limited)
+			}
+		})
+	}
+}
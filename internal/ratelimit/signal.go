@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/apierror"
+)
+
+// retryAfterPattern matches retry hints such as "retry-after: 20", "Retry after 1.5s", or "retry_after_ms=500".
+var retryAfterPattern = regexp.MustCompile(`(?i)retry[-_ ]after(_ms)?["']?\s*[:=]?\s*(\d+(?:\.\d+)?)\s*(ms|milliseconds?|s|secs?|seconds?)?`)
+
+// FromText reports whether text, such as CLI error output, reports an API error that is a
+// rate limit error, and returns the retry-after hint it contains, or zero if there is none.
+// An API error is a rate limit error if its type is rate_limit_error or, if it has no type,
+// its HTTP status is 429.
+func FromText(text string) (time.Duration, bool) {
+	if !rateLimited(text) {
+		return 0, false
+	}
+
+	match := retryAfterPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0, true
+	}
+	value, err := strconv.ParseFloat(match[2], 64)
+	if err != nil {
+		return 0, true
+	}
+	unit := time.Second
+	if match[1] != "" || strings.HasPrefix(strings.ToLower(match[3]), "m") {
+		unit = time.Millisecond
+	}
+	return time.Duration(value * float64(unit)), true
+}
+
+// rateLimited reports whether text reports an API error that is a rate limit error.
+func rateLimited(text string) bool {
+	for _, apiErr := range apierror.Find(text) {
+		if apiErr.Type != "" {
+			if apiErr.Type == "rate_limit_error" {
+				return true
+			}
+			continue
+		}
+		if apiErr.Status == "429" {
+			return true
+		}
+	}
+	return false
+}
+
+// FromEvent reports whether a system event with the given subtype and data reports a rate
+// limit that is still in effect, and returns how long to wait before the next request, or
+// zero if it does not say.
+// The wait is read from retry_after_ms, retry_after (seconds), or resets_at (Unix seconds or RFC 3339).
+func FromEvent(subtype string, data map[string]any, now time.Time) (time.Duration, bool) {
+	limited := strings.Contains(strings.ToLower(subtype), "rate_limit")
+	if status, ok := data["status"].(float64); ok && status == 429 {
+		limited = true
+	}
+	if message, ok := data["error"].(string); ok {
+		if _, ok := FromText(message); ok {
+			limited = true
+		}
+	}
+	if !limited {
+		return 0, false
+	}
+
+	if ms, ok := data["retry_after_ms"].(float64); ok {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+	if seconds, ok := data["retry_after"].(float64); ok {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	var resetsAt time.Time
+	switch value := data["resets_at"].(type) {
+	case float64:
+		resetsAt = time.Unix(int64(value), 0)
+	case string:
+		resetsAt, _ = time.Parse(time.RFC3339, value)
+	}
+	if !resetsAt.IsZero() {
+		// A limit that has already reset needs no pause
+		wait := resetsAt.Sub(now)
+		if wait <= 0 {
+			return 0, false
+		}
+		return wait, true
+	}
+	return 0, true
+}
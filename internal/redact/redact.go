@@ -0,0 +1,239 @@
+// Package redact scrubs secrets such as API keys and tokens from text before it is
+// logged or reported in errors.
+package redact
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultReplacement replaces redacted secrets.
+const DefaultReplacement = "[REDACTED]"
+
+// SecretGroup is the name of the regular expression group that holds the secret in a
+// pattern. If a pattern has no such group, the whole match is redacted.
+const SecretGroup = "secret"
+
+// DefaultPatterns match common credential formats: Anthropic, OpenAI, GitHub, AWS, and Slack
+// keys, bearer tokens, private key blocks, and the values assigned to names such as
+// api_key, access_token, client_secret, or password.
+var DefaultPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`sk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`),
+	regexp.MustCompile(`(?:gh[pousr]_[A-Za-z0-9]{30,}|github_pat_[A-Za-z0-9_]{30,})`),
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`),
+	regexp.MustCompile(`xox[abprs]-[A-Za-z0-9-]{10,}`),
+	regexp.MustCompile(`\bBearer\s+(?P<secret>[A-Za-z0-9._~+/=-]{8,})`),
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`(?i)\b[A-Za-z0-9_-]*(?:api[_-]?key|token|secret|password|passwd)(?:[_-][A-Za-z0-9_-]*)?["']?\s*[:=]\s*["']?(?P<secret>[^\s"',;&\[\]{}]{4,})`),
+}
+
+// Options configures a Redactor.
+type Options struct {
+	// Patterns are additional regular expressions matching secrets. If a pattern has a
+	// group named "secret", only that group is redacted; otherwise the whole match is.
+	Patterns []*regexp.Regexp
+	// Secrets are literal values that are always redacted, such as API keys known to the application.
+	Secrets []string
+	// Replacement replaces every redacted secret. Defaults to DefaultReplacement.
+	Replacement string
+	// DisableDefaultPatterns drops DefaultPatterns, leaving only Patterns and Secrets.
+	DisableDefaultPatterns bool
+}
+
+// Redactor replaces secrets in text. A nil *Redactor leaves text unchanged.
+// It is safe for concurrent use.
+type Redactor struct {
+	patterns    []*regexp.Regexp
+	secrets     []string
+	replacement string
+}
+
+// New creates a Redactor with the given options, which may be nil.
+func New(options *Options) *Redactor {
+	o := Options{}
+	if options != nil {
+		o = *options
+	}
+	if o.Replacement == "" {
+		o.Replacement = DefaultReplacement
+	}
+
+	r := &Redactor{replacement: o.Replacement}
+	if !o.DisableDefaultPatterns {
+		r.patterns = append(r.patterns, DefaultPatterns...)
+	}
+	r.patterns = append(r.patterns, o.Patterns...)
+	return r.With(o.Secrets...)
+}
+
+// With returns a copy of the Redactor that also redacts the given literal secrets.
+// Empty secrets are ignored.
+func (r *Redactor) With(secrets ...string) *Redactor {
+	if r == nil {
+		r = &Redactor{replacement: DefaultReplacement}
+	}
+	extended := &Redactor{patterns: r.patterns, secrets: slices.Clone(r.secrets), replacement: r.replacement}
+	for _, secret := range secrets {
+		if secret != "" && !slices.Contains(extended.secrets, secret) {
+			extended.secrets = append(extended.secrets, secret)
+		}
+	}
+	// Longer secrets go first so that a secret containing another is redacted whole
+	slices.SortStableFunc(extended.secrets, func(a, b string) int { return len(b) - len(a) })
+	return extended
+}
+
+// String returns text with every secret replaced.
+func (r *Redactor) String(text string) string {
+	if r == nil || text == "" {
+		return text
+	}
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, r.replacement)
+	}
+	for _, pattern := range r.patterns {
+		text = r.replacePattern(pattern, text)
+	}
+	return text
+}
+
+// replacePattern replaces the matches of pattern, or only their secret groups, in text.
+func (r *Redactor) replacePattern(pattern *regexp.Regexp, text string) string {
+	group := pattern.SubexpIndex(SecretGroup)
+	matches := pattern.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		if group > 0 {
+			start, end = match[2*group], match[2*group+1]
+			if start < 0 {
+				continue
+			}
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(r.replacement)
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Strings returns a copy of values with every secret replaced.
+func (r *Redactor) Strings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	redacted := make([]string, len(values))
+	for i, value := range values {
+		redacted[i] = r.String(value)
+	}
+	return redacted
+}
+
+// Value returns a copy of a decoded JSON value, such as tool input, with every secret in its
+// strings replaced. Maps and slices are copied; other values are returned as they are.
+func (r *Redactor) Value(value any) any {
+	switch v := value.(type) {
+	case string:
+		return r.String(v)
+	case []string:
+		return r.Strings(v)
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key, item := range v {
+			redacted[key] = r.Value(item)
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, item := range v {
+			redacted[i] = r.Value(item)
+		}
+		return redacted
+	default:
+		return value
+	}
+}
+
+// Handler returns a slog.Handler that redacts the message and attribute values of every
+// record before passing it to handler.
+func (r *Redactor) Handler(handler slog.Handler) slog.Handler {
+	if r == nil {
+		return handler
+	}
+	return &redactingHandler{redactor: r, next: handler}
+}
+
+// Logger returns a logger that redacts everything it logs before passing it to logger.
+func (r *Redactor) Logger(logger *slog.Logger) *slog.Logger {
+	if r == nil {
+		return logger
+	}
+	return slog.New(r.Handler(logger.Handler()))
+}
+
+// redactingHandler is the slog.Handler returned by Redactor.Handler.
+type redactingHandler struct {
+	redactor *Redactor
+	next     slog.Handler
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.redactor.String(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.attr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.attr(attr)
+	}
+	return &redactingHandler{redactor: h.redactor, next: h.next.WithAttrs(redacted)}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{redactor: h.redactor, next: h.next.WithGroup(name)}
+}
+
+// attr redacts the strings in an attribute value. Errors and values with a String method
+// are logged as their redacted text.
+func (h *redactingHandler) attr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, h.redactor.String(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, len(group))
+		for i, member := range group {
+			redacted[i] = h.attr(member)
+		}
+		return slog.Group(attr.Key, redacted...)
+	case slog.KindAny:
+		switch v := value.Any().(type) {
+		case error:
+			return slog.String(attr.Key, h.redactor.String(v.Error()))
+		case interface{ String() string }:
+			return slog.String(attr.Key, h.redactor.String(v.String()))
+		case []string, map[string]any, []any:
+			return slog.Any(attr.Key, h.redactor.Value(v))
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
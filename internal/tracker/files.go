@@ -0,0 +1,254 @@
+package tracker
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/types"
+)
+
+// FileOperation identifies the tool that modified a file.
+type FileOperation string
+
+// File operation constants
+const (
+	FileOperationWrite        FileOperation = "write"
+	FileOperationEdit         FileOperation = "edit"
+	FileOperationMultiEdit    FileOperation = "multi_edit"
+	FileOperationNotebookEdit FileOperation = "notebook_edit"
+)
+
+// FileChangeStatus is the outcome of a file-modifying tool call.
+type FileChangeStatus string
+
+// File change status constants
+const (
+	// FileChangePending means the tool call has been issued but no result has been reported yet.
+	FileChangePending FileChangeStatus = "pending"
+	// FileChangeSucceeded means the tool reported a successful result.
+	FileChangeSucceeded FileChangeStatus = "succeeded"
+	// FileChangeFailed means the tool reported an error.
+	FileChangeFailed FileChangeStatus = "failed"
+)
+
+// FileChange describes a single file-modifying tool call and its outcome.
+type FileChange struct {
+	// ToolUseID is the ID of the tool use that modified the file.
+	ToolUseID string
+	// Path is the file targeted by the tool call.
+	Path string
+	// Operation is the kind of modification.
+	Operation FileOperation
+	// Status is the outcome of the tool call.
+	Status FileChangeStatus
+	// Error contains the tool output when Status is FileChangeFailed.
+	Error string
+	// Before is the file content captured when the tool call was observed.
+	// It is nil if no snapshot function is configured or the file did not exist.
+	Before []byte
+	// After is the file content captured when the tool result was observed.
+	// It is nil if no snapshot function is configured, the file does not exist, or the call failed.
+	After []byte
+}
+
+// SnapshotFunc captures the content of a file. It should return nil content and a nil
+// error for files that do not exist.
+type SnapshotFunc func(path string) ([]byte, error)
+
+// ReadFileSnapshot is a SnapshotFunc that reads the file from the local file system.
+func ReadFileSnapshot(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return content, err
+}
+
+// FileChangeTrackerOptions configures a FileChangeTracker.
+type FileChangeTrackerOptions struct {
+	// Snapshot, if set, is used to capture file contents before and after each change.
+	// Snapshots are only meaningful when the tracker observes the stream of a query
+	// running on the same file system, as it happens.
+	Snapshot SnapshotFunc
+	// OnChange, if set, is called synchronously when a tool result completes a change.
+	OnChange func(FileChange)
+}
+
+// FileChangeTracker records the files modified by Write, Edit, MultiEdit and NotebookEdit
+// tool calls in a message stream, matching each tool use with its tool result.
+// It is safe for concurrent use.
+type FileChangeTracker struct {
+	mu      sync.Mutex
+	changes []FileChange
+	index   map[string]int
+	options FileChangeTrackerOptions
+}
+
+// NewFileChangeTracker creates a FileChangeTracker. A nil options is treated as zero options.
+func NewFileChangeTracker(options *FileChangeTrackerOptions) *FileChangeTracker {
+	t := &FileChangeTracker{index: make(map[string]int)}
+	if options != nil {
+		t.options = *options
+	}
+	return t
+}
+
+// Observe updates the tracker from a single message. Tool uses are read from assistant
+// messages and tool results from user messages; all other messages are ignored.
+func (t *FileChangeTracker) Observe(message types.Message) {
+	switch msg := message.(type) {
+	case *types.AssistantMessage:
+		for _, block := range msg.Content {
+			if toolUse, ok := block.(*types.ToolUseBlock); ok {
+				t.begin(toolUse)
+			}
+		}
+	case *types.UserMessage:
+		for _, block := range msg.ContentBlocks {
+			if toolResult, ok := block.(*types.ToolResultBlock); ok {
+				t.complete(toolResult)
+			}
+		}
+	}
+}
+
+// Track forwards every message from in to the returned channel while observing it.
+// The returned channel is closed once in is closed.
+func (t *FileChangeTracker) Track(in <-chan types.Message) <-chan types.Message {
+	out := make(chan types.Message, cap(in))
+	go func() {
+		defer close(out)
+		for message := range in {
+			t.Observe(message)
+			out <- message
+		}
+	}()
+	return out
+}
+
+// Changes returns every recorded change in the order the tool calls were observed.
+func (t *FileChangeTracker) Changes() []FileChange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.changes)
+}
+
+// ChangedPaths returns the sorted, de-duplicated paths of all successfully modified files.
+func (t *FileChangeTracker) ChangedPaths() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var paths []string
+	for _, change := range t.changes {
+		if change.Status == FileChangeSucceeded {
+			paths = append(paths, change.Path)
+		}
+	}
+	slices.Sort(paths)
+	return slices.Compact(paths)
+}
+
+func (t *FileChangeTracker) begin(toolUse *types.ToolUseBlock) {
+	operation, ok := fileOperationOf(toolUse.Name)
+	if !ok {
+		return
+	}
+	path, ok := toolUse.FilePath()
+	if !ok || path == "" {
+		return
+	}
+
+	change := FileChange{
+		ToolUseID: toolUse.ID,
+		Path:      path,
+		Operation: operation,
+		Status:    FileChangePending,
+	}
+	if t.options.Snapshot != nil {
+		// A failed snapshot leaves Before empty; the change itself is still recorded.
+		change.Before, _ = t.options.Snapshot(path)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, exists := t.index[toolUse.ID]; exists {
+		return
+	}
+	t.index[toolUse.ID] = len(t.changes)
+	t.changes = append(t.changes, change)
+}
+
+func (t *FileChangeTracker) complete(toolResult *types.ToolResultBlock) {
+	t.mu.Lock()
+	i, ok := t.index[toolResult.ToolUseID]
+	if !ok || t.changes[i].Status != FileChangePending {
+		t.mu.Unlock()
+		return
+	}
+	path := t.changes[i].Path
+	t.mu.Unlock()
+
+	failed := toolResult.IsError != nil && *toolResult.IsError
+	var after []byte
+	if !failed && t.options.Snapshot != nil {
+		after, _ = t.options.Snapshot(path)
+	}
+
+	t.mu.Lock()
+	change := &t.changes[i]
+	if failed {
+		change.Status = FileChangeFailed
+		change.Error = toolResultText(toolResult.Content)
+	} else {
+		change.Status = FileChangeSucceeded
+		change.After = after
+	}
+	completed := *change
+	onChange := t.options.OnChange
+	t.mu.Unlock()
+
+	if onChange != nil {
+		onChange(completed)
+	}
+}
+
+func fileOperationOf(toolName string) (FileOperation, bool) {
+	switch toolName {
+	case types.ToolWrite:
+		return FileOperationWrite, true
+	case types.ToolEdit:
+		return FileOperationEdit, true
+	case types.ToolMultiEdit:
+		return FileOperationMultiEdit, true
+	case types.ToolNotebookEdit:
+		return FileOperationNotebookEdit, true
+	default:
+		return "", false
+	}
+}
+
+// toolResultText extracts the text of a tool result, which is either a string
+// or a list of text content blocks.
+func toolResultText(content any) string {
+	switch c := content.(type) {
+	case nil:
+		return ""
+	case string:
+		return c
+	case []any:
+		var text string
+		for _, item := range c {
+			if block, ok := item.(map[string]any); ok {
+				if s, ok := block["text"].(string); ok {
+					text += s
+				}
+			}
+		}
+		return text
+	default:
+		return fmt.Sprint(c)
+	}
+}
@@ -0,0 +1,148 @@
+package tracker
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/types"
+)
+
+// TodoProgress summarizes a todo list.
+type TodoProgress struct {
+	// Total is the number of todos in the list.
+	Total int
+	// Completed is the number of completed todos.
+	Completed int
+	// InProgress is the number of todos currently being worked on.
+	InProgress int
+	// Pending is the number of todos that have not been started.
+	Pending int
+}
+
+func (p TodoProgress) String() string {
+	return fmt.Sprintf("%d/%d tasks complete", p.Completed, p.Total)
+}
+
+// TodoChange describes an update of the todo list reported by a TodoWrite tool call.
+type TodoChange struct {
+	// ToolUseID is the ID of the TodoWrite tool use that produced the change.
+	ToolUseID string
+	// Previous is the todo list before the change.
+	Previous []types.TodoItem
+	// Current is the todo list after the change.
+	Current []types.TodoItem
+	// Progress summarizes Current.
+	Progress TodoProgress
+}
+
+// TodoTracker maintains the agent's current todo list from the TodoWrite tool calls
+// in a message stream. It is safe for concurrent use.
+type TodoTracker struct {
+	mu       sync.Mutex
+	todos    []types.TodoItem
+	onChange func(TodoChange)
+}
+
+// NewTodoTracker creates a TodoTracker. If onChange is non-nil, it is called synchronously
+// for every TodoWrite call that changes the list.
+func NewTodoTracker(onChange func(TodoChange)) *TodoTracker {
+	return &TodoTracker{onChange: onChange}
+}
+
+// Observe updates the tracker from a single message. Messages other than assistant
+// messages containing TodoWrite tool uses are ignored.
+func (t *TodoTracker) Observe(message types.Message) {
+	assistantMsg, ok := message.(*types.AssistantMessage)
+	if !ok {
+		return
+	}
+
+	for _, block := range assistantMsg.Content {
+		toolUse, ok := block.(*types.ToolUseBlock)
+		if !ok || toolUse.Name != types.ToolTodoWrite {
+			continue
+		}
+		var input types.TodoWriteInput
+		if err := toolUse.DecodeInput(&input); err != nil {
+			continue
+		}
+		t.update(toolUse.ID, input.Todos)
+	}
+}
+
+// Track forwards every message from in to the returned channel while observing it.
+// The returned channel is closed once in is closed.
+func (t *TodoTracker) Track(in <-chan types.Message) <-chan types.Message {
+	out := make(chan types.Message, cap(in))
+	go func() {
+		defer close(out)
+		for message := range in {
+			t.Observe(message)
+			out <- message
+		}
+	}()
+	return out
+}
+
+// Todos returns a copy of the current todo list.
+func (t *TodoTracker) Todos() []types.TodoItem {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.todos)
+}
+
+// Progress summarizes the current todo list.
+func (t *TodoTracker) Progress() TodoProgress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return progressOf(t.todos)
+}
+
+// Current returns the todo currently in progress, if any.
+func (t *TodoTracker) Current() (types.TodoItem, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, todo := range t.todos {
+		if todo.Status == types.TodoStatusInProgress {
+			return todo, true
+		}
+	}
+	return types.TodoItem{}, false
+}
+
+func (t *TodoTracker) update(toolUseID string, todos []types.TodoItem) {
+	t.mu.Lock()
+	if slices.Equal(t.todos, todos) {
+		t.mu.Unlock()
+		return
+	}
+	change := TodoChange{
+		ToolUseID: toolUseID,
+		Previous:  t.todos,
+		Current:   slices.Clone(todos),
+		Progress:  progressOf(todos),
+	}
+	t.todos = slices.Clone(todos)
+	onChange := t.onChange
+	t.mu.Unlock()
+
+	if onChange != nil {
+		onChange(change)
+	}
+}
+
+func progressOf(todos []types.TodoItem) TodoProgress {
+	progress := TodoProgress{Total: len(todos)}
+	for _, todo := range todos {
+		switch todo.Status {
+		case types.TodoStatusCompleted:
+			progress.Completed++
+		case types.TodoStatusInProgress:
+			progress.InProgress++
+		default:
+			progress.Pending++
+		}
+	}
+	return progress
+}
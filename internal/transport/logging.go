@@ -0,0 +1,81 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/redact"
+)
+
+// maxLoggedLineSize is the longest excerpt of a skipped stdout line that is logged.
+const maxLoggedLineSize = 256
+
+// redactedValue replaces secret values in logged arguments.
+const redactedValue = "[REDACTED]"
+
+// discardLogger is used when no logger is configured.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// summarizedFlags take values that are too large or too sensitive to log verbatim,
+// such as prompts and inline settings, which may carry environment variables.
+var summarizedFlags = map[string]bool{
+	"--print":                true,
+	"--system-prompt":        true,
+	"--append-system-prompt": true,
+	"--settings":             true,
+	"--agents":               true,
+}
+
+// redactArgs returns a copy of the CLI arguments that is safe to log. Prompts and inline
+// settings are replaced by their size, the environment variables and headers of
+// MCP servers are redacted, and redactor scrubs the remaining arguments.
+func redactArgs(args []string, redactor *redact.Redactor) []string {
+	redacted := make([]string, len(args))
+	for i := 0; i < len(args); i++ {
+		redacted[i] = redactor.String(args[i])
+		if i == len(args)-1 {
+			break
+		}
+		switch {
+		case summarizedFlags[args[i]]:
+			i++
+			redacted[i] = fmt.Sprintf("[%d bytes]", len(args[i]))
+		case args[i] == "--mcp-config":
+			i++
+			redacted[i] = redactor.String(redactMcpConfig(args[i]))
+		}
+	}
+	return redacted
+}
+
+// redactMcpConfig redacts the env and headers values of every server in an inline MCP configuration.
+func redactMcpConfig(config string) string {
+	var parsed struct {
+		McpServers map[string]map[string]any `json:"mcpServers"`
+	}
+	if err := json.Unmarshal([]byte(config), &parsed); err != nil {
+		return redactedValue
+	}
+	for _, server := range parsed.McpServers {
+		for _, field := range []string{"env", "headers"} {
+			values, ok := server[field].(map[string]any)
+			if !ok {
+				continue
+			}
+			for key := range values {
+				values[key] = redactedValue
+			}
+		}
+	}
+	redacted, _ := json.Marshal(map[string]any{"mcpServers": parsed.McpServers})
+	return string(redacted)
+}
+
+// excerpt shortens a line of CLI output for logging.
+func excerpt(line string) string {
+	if len(line) <= maxLoggedLineSize {
+		return line
+	}
+	return line[:maxLoggedLineSize] + fmt.Sprintf("... [%d bytes]", len(line))
+}
@@ -6,16 +6,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/musaprg/claude-code-sdk-go/internal/cli"
 	"github.com/musaprg/claude-code-sdk-go/internal/errors"
 	"github.com/musaprg/claude-code-sdk-go/internal/parser"
+	"github.com/musaprg/claude-code-sdk-go/internal/redact"
 	"github.com/musaprg/claude-code-sdk-go/internal/types"
 )
 
@@ -23,28 +27,190 @@ const (
 	maxBufferSize = 1024 * 1024      // 1MB buffer limit
 	maxStderrSize = 10 * 1024 * 1024 // 10MB stderr limit
 	stderrTimeout = 30 * time.Second
+
+	gracefulShutdownTimeout = 5 * time.Second
+
+	// minSecretSize is the length from which MCP server environment variables and headers are redacted.
+	minSecretSize = 8
+	// maxArgvPromptSize is the largest prompt passed via argv in PromptDeliveryAuto mode.
+	// Larger prompts are streamed over stdin to stay well clear of ARG_MAX.
+	maxArgvPromptSize = 16 * 1024
+)
+
+// Config contains the settings used to launch the Claude Code CLI subprocess.
+type Config struct {
+	// CLIPath is the path to the CLI executable. If empty, it is discovered with cli.FindCLI.
+	CLIPath string
+	// NodePath is the Node.js binary used when CLIPath is a JavaScript entry point such as cli.js.
+	// If empty, "node" is looked up in PATH.
+	NodePath string
+	// CWD is the working directory of the subprocess.
+	CWD string
+	// PromptDelivery controls whether prompts are passed via argv or stdin.
+	PromptDelivery types.PromptDelivery
+	// McpConfigDelivery controls whether MCP server configurations are passed via argv or a temporary file.
+	McpConfigDelivery types.McpConfigDelivery
+	// SkipVersionCheck disables CLI version detection; all CLI features are then assumed available.
+	SkipVersionCheck bool
+	// Metrics receives process start latencies and stderr truncations, if set.
+	Metrics types.Metrics
+	// Logger receives process lifecycle events, stderr output, and skipped output lines.
+	// If nil, nothing is logged.
+	Logger *slog.Logger
+	// Redactor scrubs secrets from log records and process errors, in addition to the
+	// environment variables and headers of MCP servers. If nil, only those are redacted.
+	Redactor *redact.Redactor
+}
+
+// state represents the lifecycle stage of a SubprocessTransport.
+type state int
+
+const (
+	// stateIdle means Connect has not been called yet.
+	stateIdle state = iota
+	// stateConnecting means the subprocess is being started.
+	stateConnecting
+	// stateRunning means the subprocess has been started and may be producing output.
+	stateRunning
+	// stateClosing means Close is terminating the subprocess.
+	stateClosing
+	// stateClosed means the transport has been shut down and cannot be reused.
+	stateClosed
 )
 
-// SubprocessTransport handles communication with Claude CLI via subprocess
+func (s state) String() string {
+	switch s {
+	case stateIdle:
+		return "idle"
+	case stateConnecting:
+		return "connecting"
+	case stateRunning:
+		return "running"
+	case stateClosing:
+		return "closing"
+	case stateClosed:
+		return "closed"
+	default:
+		return fmt.Sprintf("state(%d)", int(s))
+	}
+}
+
+// SubprocessTransport handles communication with Claude CLI via subprocess.
+// It is safe for concurrent use: Close may be called from any goroutine, any number of times,
+// while ReceiveMessages is still delivering output.
 type SubprocessTransport struct {
-	cliPath string
-	cwd     string
-	cmd     *exec.Cmd
-	stdin   io.WriteCloser
-	stdout  io.ReadCloser
-	stderr  io.ReadCloser
+	cliPath           string
+	nodePath          string
+	cwd               string
+	promptDelivery    types.PromptDelivery
+	mcpConfigDelivery types.McpConfigDelivery
+	skipVersionCheck  bool
+	metrics           types.Metrics
+	logger            *slog.Logger
+	redactor          *redact.Redactor
+	// version is the detected CLI version; it is zero if the version check was skipped.
+	version cli.SemVer
+	// features lists the optional capabilities of the connected CLI.
+	features cli.Features
+
+	mu        sync.Mutex
+	state     state
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	stdout    io.ReadCloser
+	stderr    io.ReadCloser
+	receiving bool
+	// awaitingPrompt is set when the process was started by Spawn and Send has not been called yet.
+	awaitingPrompt bool
+	// tempFiles are removed when the transport is closed.
+	tempFiles []string
+
+	// waitOnce guarantees cmd.Wait is called exactly once.
+	waitOnce sync.Once
+	// waitDone is closed once cmd.Wait has returned; waitErr holds its result.
+	waitDone chan struct{}
+	waitErr  error
+	// closed is closed once Close has finished tearing down the subprocess.
+	closed chan struct{}
 }
 
 // NewSubprocessTransport creates a new subprocess transport
-func NewSubprocessTransport(cliPath string, cwd string) *SubprocessTransport {
+func NewSubprocessTransport(config Config) *SubprocessTransport {
+	logger := config.Logger
+	if logger == nil {
+		logger = discardLogger
+	}
 	return &SubprocessTransport{
-		cliPath: cliPath,
-		cwd:     cwd,
+		logger:            logger,
+		redactor:          config.Redactor,
+		cliPath:           config.CLIPath,
+		nodePath:          config.NodePath,
+		cwd:               config.CWD,
+		promptDelivery:    config.PromptDelivery,
+		mcpConfigDelivery: config.McpConfigDelivery,
+		skipVersionCheck:  config.SkipVersionCheck,
+		metrics:           config.Metrics,
+		features:          cli.AllFeatures(),
+		waitDone:          make(chan struct{}),
+		closed:            make(chan struct{}),
 	}
 }
 
 // Connect starts the subprocess
-func (t *SubprocessTransport) Connect(ctx context.Context, options *types.QueryOptions, prompt string) error {
+func (t *SubprocessTransport) Connect(ctx context.Context, options *types.QueryOptions, prompt *types.Prompt) error {
+	return t.connect(ctx, "connect", options, prompt)
+}
+
+// Spawn starts the subprocess for a query with the given options without sending a prompt.
+// The CLI reads its prompt from stdin in stream-json input format and waits until Send is called,
+// which lets callers pay the CLI startup cost ahead of time.
+func (t *SubprocessTransport) Spawn(ctx context.Context, options *types.QueryOptions) error {
+	return t.connect(ctx, "spawn", options, nil)
+}
+
+// connect implements Connect and Spawn. Locating the CLI and checking its version run external
+// commands, so t.mu is released meanwhile and Close does not wait for them; the process is
+// only started if the transport was not closed in the meantime.
+func (t *SubprocessTransport) connect(ctx context.Context, action string, options *types.QueryOptions, prompt *types.Prompt) error {
+	t.mu.Lock()
+	if t.state != stateIdle {
+		defer t.mu.Unlock()
+		return errors.NewCLIConnectionError(
+			fmt.Sprintf("cannot %s transport in %s state", action, t.state), nil)
+	}
+	t.state = stateConnecting
+	t.mu.Unlock()
+
+	began := time.Now()
+	err := t.resolveCLI()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err == nil && t.state != stateConnecting {
+		err = errors.NewCLIConnectionError("transport was closed while connecting", nil)
+	}
+	if err == nil {
+		err = t.start(ctx, began, options, prompt)
+	}
+	if err != nil {
+		t.removeTempFiles()
+		// Close may already have finished tearing the transport down
+		if t.state == stateConnecting {
+			t.state = stateClosed
+			close(t.closed)
+		}
+		return err
+	}
+
+	t.state = stateRunning
+	t.awaitingPrompt = prompt == nil
+	return nil
+}
+
+// resolveCLI locates the CLI unless a path was configured, and verifies that it is new enough
+// and learns which optional flags it understands unless the version check is skipped.
+// It is only called while the transport is connecting, so it does not need t.mu.
+func (t *SubprocessTransport) resolveCLI() error {
 	if t.cliPath == "" {
 		var err error
 		t.cliPath, err = cli.FindCLI()
@@ -53,75 +219,251 @@ func (t *SubprocessTransport) Connect(ctx context.Context, options *types.QueryO
 		}
 	}
 
+	if !t.skipVersionCheck {
+		version, err := cli.CheckVersion(t.cliPath, t.nodePath)
+		if err != nil {
+			return err
+		}
+		t.version = version
+		t.features = cli.FeaturesFor(version)
+	}
+	return nil
+}
+
+// Send delivers the prompt to a subprocess started by Spawn. It may be called only once.
+func (t *SubprocessTransport) Send(prompt *types.Prompt) error {
+	if err := prompt.Err(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.state != stateRunning || !t.awaitingPrompt {
+		return errors.NewCLIConnectionError(
+			fmt.Sprintf("cannot send a prompt to a %s transport that is not awaiting one", t.state), nil)
+	}
+	t.awaitingPrompt = false
+
+	var content any = prompt
+	if text, ok := prompt.PlainText(); ok {
+		content = text
+	}
+	go writePrompt(t.stdin, content)
+	return nil
+}
+
+// Exited reports whether the subprocess has exited. It only becomes true once
+// ReceiveMessages has read all of the process output.
+func (t *SubprocessTransport) Exited() bool {
+	select {
+	case <-t.waitDone:
+		return true
+	default:
+		return false
+	}
+}
+
+// mcpConfigJSON returns the --mcp-config document describing servers.
+func mcpConfigJSON(servers map[string]types.McpServerConfig) []byte {
+	configJSON, _ := json.Marshal(map[string]any{"mcpServers": servers})
+	return configJSON
+}
+
+// mcpCredentials returns the environment variables or headers of an MCP server, which
+// commonly hold API keys and tokens.
+func mcpCredentials(server types.McpServerConfig) map[string]string {
+	switch s := server.(type) {
+	case types.McpStdioServer:
+		return s.Env
+	case types.McpSSEServer:
+		return s.Headers
+	case types.McpHTTPServer:
+		return s.Headers
+	default:
+		return nil
+	}
+}
+
+// hasMcpCredentials reports whether any of servers has environment variables or headers.
+func hasMcpCredentials(servers map[string]types.McpServerConfig) bool {
+	for _, server := range servers {
+		if len(mcpCredentials(server)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// mcpSecrets returns the environment variable and header values of servers that are long
+// enough to be credentials. Shorter values, such as flags, would redact too much unrelated text.
+func mcpSecrets(servers map[string]types.McpServerConfig) []string {
+	var secrets []string
+	for _, server := range servers {
+		for _, value := range mcpCredentials(server) {
+			if len(value) >= minSecretSize {
+				secrets = append(secrets, value)
+			}
+		}
+	}
+	return secrets
+}
+
+// start spawns the CLI process found by resolveCLI, reporting its start latency since began.
+// A nil prompt starts the process waiting for the prompt on stdin (see Spawn). The caller must hold t.mu.
+func (t *SubprocessTransport) start(ctx context.Context, began time.Time, options *types.QueryOptions, prompt *types.Prompt) error {
+	// Everything logged or reported about this process is scrubbed of its MCP servers' credentials
+	if options != nil {
+		t.redactor = t.redactor.With(mcpSecrets(options.McpServers)...)
+	}
+	t.logger = t.redactor.Logger(t.logger)
+
+	if prompt != nil {
+		if err := prompt.Err(); err != nil {
+			return err
+		}
+	}
+
+	if err := t.checkFeatures(options); err != nil {
+		return err
+	}
+
+	plan, err := t.planPrompt(options, prompt)
+	if err != nil {
+		return err
+	}
+	if plan.mcpConfigFile, err = t.writeMcpConfig(options); err != nil {
+		return err
+	}
+
 	// Build command arguments
-	args := t.buildCommand(options, prompt)
+	name, args, err := cli.Command(t.cliPath, t.nodePath)
+	if err != nil {
+		return err
+	}
+	args = append(args, t.buildCommand(options, plan)...)
 
-	t.cmd = exec.CommandContext(ctx, t.cliPath, args...)
+	cmd := exec.CommandContext(ctx, name, args...)
 
-	// Set working directory
-	if t.cwd != "" {
-		if _, err := os.Stat(t.cwd); err != nil {
+	// Set working directory, preferring the per-query override
+	cwd := t.cwd
+	if options != nil && options.CWD != "" {
+		cwd = options.CWD
+	}
+	if cwd != "" {
+		if _, err := os.Stat(cwd); err != nil {
 			return errors.NewCLIConnectionError(
-				fmt.Sprintf("Working directory does not exist: %s", t.cwd), err)
+				fmt.Sprintf("Working directory does not exist: %s", cwd), err)
+		}
+		cmd.Dir = cwd
+	}
+
+	// Run as a different operating system user if requested, who must be able to read the temporary files
+	if options != nil && options.User != "" {
+		if err := setUser(cmd, options.User, t.tempFiles); err != nil {
+			return err
 		}
-		t.cmd.Dir = t.cwd
 	}
 
 	// Set environment
-	t.cmd.Env = append(os.Environ(),
+	cmd.Env = append(os.Environ(),
 		"CLAUDE_CODE_ENTRYPOINT=sdk-go",
 		"FORCE_COLOR=0",       // Disable color output which might affect buffering
 		"NODE_ENV=production") // Ensure consistent node environment
 
 	// Set up pipes
-	var err error
-	t.stdin, err = t.cmd.StdinPipe()
+	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return errors.NewCLIConnectionError("failed to create stdin pipe", err)
 	}
 
-	t.stdout, err = t.cmd.StdoutPipe()
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return errors.NewCLIConnectionError("failed to create stdout pipe", err)
 	}
 
-	t.stderr, err = t.cmd.StderrPipe()
+	stderr, err := cmd.StderrPipe()
 	if err != nil {
 		return errors.NewCLIConnectionError("failed to create stderr pipe", err)
 	}
 
 	// Start the process
-	if err := t.cmd.Start(); err != nil {
+	if err := cmd.Start(); err != nil {
 		// Check if the error is due to missing CLI
-		if filepath.Base(t.cliPath) == "claude" {
+		if filepath.Base(t.cliPath) == "claude" || !fileExists(name) {
 			return errors.NewCLINotFoundError(t.cliPath, err)
 		}
 		return errors.NewProcessError("failed to start CLI process", 0, "", err)
 	}
-
-	// Close stdin immediately since we're using --print mode
-	// This prevents the CLI from waiting for interactive input
-	if t.stdin != nil {
-		t.stdin.Close()
+	latency := time.Since(began)
+	if t.metrics != nil {
+		t.metrics.ProcessStarted(latency)
+	}
+	t.logger = t.logger.With("pid", cmd.Process.Pid)
+	t.logger.Debug("started CLI process", "path", name, "args", redactArgs(args, t.redactor), "cwd", cmd.Dir, "latency", latency)
+
+	switch {
+	case plan.stdin && plan.content != nil:
+		// Stream the prompt in the background so a large payload cannot block Connect
+		// while the CLI is still starting up; stdin is closed once it has been written.
+		go writePrompt(stdin, plan.content)
+	case plan.stdin:
+		// The prompt is delivered later by Send
+	default:
+		// Close stdin immediately since we're using --print mode
+		// This prevents the CLI from waiting for interactive input
+		stdin.Close()
 	}
 
+	t.cmd = cmd
+	t.stdin = stdin
+	t.stdout = stdout
+	t.stderr = stderr
 	return nil
 }
 
-// ReceiveMessages receives and parses messages from the CLI
+// ReceiveMessages receives and parses messages from the CLI.
+// It may be called at most once per connection.
 func (t *SubprocessTransport) ReceiveMessages(ctx context.Context) (<-chan types.Message, error) {
-	if t.cmd == nil {
-		return nil, errors.NewCLIConnectionError("not connected", nil)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.state != stateRunning {
+		return nil, errors.NewCLIConnectionError(
+			fmt.Sprintf("not connected (transport is %s)", t.state), nil)
 	}
+	if t.receiving {
+		return nil, errors.NewCLIConnectionError("messages are already being received", nil)
+	}
+	t.receiving = true
 
 	messageCh := make(chan types.Message, 10)
+	stdout := t.stdout
+	logger := t.logger
+	redactor := t.redactor
+	var onTruncate func()
+	if t.metrics != nil {
+		onTruncate = t.metrics.StderrTruncated
+	}
+	stderr := collectStderr(t.stderr, logger, onTruncate)
 
 	go func() {
 		defer close(messageCh)
-		defer t.cleanup()
+
+		// Sends give up once the consumer is gone: when ctx is done or the transport is closed
+		send := func(message types.Message) bool {
+			select {
+			case messageCh <- message:
+				return true
+			case <-ctx.Done():
+				return false
+			case <-t.closed:
+				return false
+			}
+		}
 
 		// Process stdout messages
-		scanner := bufio.NewScanner(t.stdout)
+		scanner := bufio.NewScanner(stdout)
 
 		for scanner.Scan() {
 			select {
@@ -137,11 +479,10 @@ func (t *SubprocessTransport) ReceiveMessages(ctx context.Context) (<-chan types
 
 			// Check buffer size limit
 			if len(line) > maxBufferSize {
+				logger.Warn("skipped oversized CLI output line", "size", len(line), "limit", maxBufferSize)
 				errorMsg := types.NewUserMessage(
 					fmt.Sprintf("JSON message exceeded maximum buffer size of %d bytes", maxBufferSize))
-				select {
-				case messageCh <- errorMsg:
-				case <-ctx.Done():
+				if !send(errorMsg) {
 					return
 				}
 				continue
@@ -151,70 +492,317 @@ func (t *SubprocessTransport) ReceiveMessages(ctx context.Context) (<-chan types
 			var data map[string]any
 			if err := json.Unmarshal([]byte(line), &data); err != nil {
 				// Skip invalid JSON lines
+				logger.Warn("skipped non-JSON CLI output line", "line", excerpt(line), "error", err)
 				continue
 			}
 
 			// Handle control responses (skip for now as they're CLI internal)
 			if messageType, ok := data["type"].(string); ok && messageType == "control_response" {
+				logger.Debug("skipped CLI control response", "line", excerpt(line))
 				continue
 			}
 
 			// Parse the message
 			message, err := parser.ParseMessage(data)
 			if err != nil {
+				logger.Warn("failed to parse CLI message", "line", excerpt(line), "error", err)
 				// Send parse error as a user message
-				errorMsg := types.NewUserMessage(fmt.Sprintf("Parse error: %v", err))
-				select {
-				case messageCh <- errorMsg:
-				case <-ctx.Done():
+				errorMsg := types.NewUserMessage("Parse error: " + redactor.String(err.Error()))
+				if !send(errorMsg) {
 					return
 				}
 				continue
 			}
 
-			select {
-			case messageCh <- message:
-			case <-ctx.Done():
+			if !send(message) {
 				return
 			}
 		}
 
-		// Handle scanner error
-		if err := scanner.Err(); err != nil && err != io.EOF {
+		// Handle scanner error, unless it merely reflects the pipe being closed by Close
+		if err := scanner.Err(); err != nil && err != io.EOF && !t.isClosing() {
+			logger.Error("failed to read CLI output", "error", err)
 			errorMsg := types.NewUserMessage(fmt.Sprintf("Scanner error: %v", err))
-			select {
-			case messageCh <- errorMsg:
-			case <-ctx.Done():
+			if !send(errorMsg) {
 				return
 			}
 		}
 
 		// Process stderr and wait for command completion
-		t.handleProcessCompletion(ctx, messageCh)
+		t.handleProcessCompletion(ctx, send, stderr)
 	}()
 
 	return messageCh, nil
 }
 
-// Disconnect closes the subprocess
+// Disconnect closes the subprocess. It is equivalent to Close.
 func (t *SubprocessTransport) Disconnect() error {
-	return t.cleanup()
+	return t.Close()
 }
 
-func (t *SubprocessTransport) buildCommand(options *types.QueryOptions, prompt string) []string {
+// Close terminates the subprocess, if any, and releases its resources.
+// Close is idempotent and safe to call concurrently; every call blocks until teardown has finished.
+func (t *SubprocessTransport) Close() error {
+	t.mu.Lock()
+	switch t.state {
+	case stateIdle:
+		t.removeTempFiles()
+		t.state = stateClosed
+		close(t.closed)
+		t.mu.Unlock()
+		return nil
+	case stateClosing, stateClosed:
+		t.mu.Unlock()
+		<-t.closed
+		return nil
+	}
+	t.state = stateClosing
+	cmd := t.cmd
+	t.mu.Unlock()
+
+	// A transport that is still connecting has no process yet, and will not start one
+	if cmd != nil {
+		t.terminate(cmd)
+	}
+
+	t.mu.Lock()
+	t.removeTempFiles()
+	t.state = stateClosed
+	close(t.closed)
+	t.mu.Unlock()
+	return nil
+}
+
+// isClosing reports whether Close has been called.
+func (t *SubprocessTransport) isClosing() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.state == stateClosing || t.state == stateClosed
+}
+
+// wait reaps the subprocess. It is safe to call from multiple goroutines;
+// only the first call invokes cmd.Wait and every call blocks until it has returned.
+func (t *SubprocessTransport) wait(cmd *exec.Cmd) error {
+	t.waitOnce.Do(func() {
+		t.waitErr = cmd.Wait()
+		close(t.waitDone)
+	})
+	<-t.waitDone
+	return t.waitErr
+}
+
+// terminate stops cmd, trying a graceful interrupt before killing it.
+func (t *SubprocessTransport) terminate(cmd *exec.Cmd) {
+	select {
+	case <-t.waitDone:
+		// Process already exited and has been reaped
+		return
+	default:
+	}
+
+	go t.wait(cmd)
+
+	// Try graceful termination first
+	t.logger.Debug("interrupting CLI process")
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		// Force kill immediately
+		t.logger.Warn("failed to interrupt CLI process, killing it", "error", err)
+		cmd.Process.Kill()
+		<-t.waitDone
+		return
+	}
+
+	// Wait a bit for graceful shutdown
+	select {
+	case <-t.waitDone:
+		// Process terminated gracefully
+	case <-time.After(gracefulShutdownTimeout):
+		// Force kill
+		t.logger.Warn("CLI process did not exit after interrupt, killing it", "timeout", gracefulShutdownTimeout)
+		cmd.Process.Kill()
+		<-t.waitDone
+	}
+}
+
+// promptPlan describes how the prompt and system prompts are handed to the CLI.
+type promptPlan struct {
+	// text is the prompt passed via argv when stdin is false.
+	text string
+	// stdin streams the prompt over stdin in stream-json input format instead of argv.
+	stdin bool
+	// content is the message content written to stdin: a string or a multimodal *types.Prompt.
+	content any
+	// systemPromptFile and appendSystemPromptFile hold system prompts that are kept out of argv.
+	systemPromptFile       string
+	appendSystemPromptFile string
+	// mcpConfigFile holds the MCP server configuration if it is kept out of argv.
+	mcpConfigFile string
+}
+
+// planPrompt decides how to deliver the prompt and system prompts, writing any
+// system prompts that must stay out of argv to private temporary files. The caller must hold t.mu.
+func (t *SubprocessTransport) planPrompt(options *types.QueryOptions, prompt *types.Prompt) (promptPlan, error) {
+	var plan promptPlan
+
+	keepOutOfArgv := func(text string) bool {
+		switch t.promptDelivery {
+		case types.PromptDeliveryArgv:
+			return false
+		case types.PromptDeliveryStdin:
+			return text != ""
+		default:
+			return len(text) > maxArgvPromptSize
+		}
+	}
+
+	// Images and documents can only be sent as stream-json content blocks
+	if prompt == nil {
+		// Deferred prompts are always sent over stdin
+		plan.stdin = true
+	} else if text, ok := prompt.PlainText(); ok {
+		plan.text = text
+		plan.stdin = keepOutOfArgv(text)
+		plan.content = text
+	} else {
+		plan.stdin = true
+		plan.content = prompt
+	}
+
+	// Older CLIs cannot read system prompts from files, so they have to stay in argv
+	if options != nil && t.features.SystemPromptFile {
+		if keepOutOfArgv(options.SystemPrompt) {
+			path, err := t.writeTempFile("system-prompt-*.txt", []byte(options.SystemPrompt))
+			if err != nil {
+				return plan, err
+			}
+			plan.systemPromptFile = path
+		}
+		if keepOutOfArgv(options.AppendSystemPrompt) {
+			path, err := t.writeTempFile("append-system-prompt-*.txt", []byte(options.AppendSystemPrompt))
+			if err != nil {
+				return plan, err
+			}
+			plan.appendSystemPromptFile = path
+		}
+	}
+
+	return plan, nil
+}
+
+// writeMcpConfig writes the MCP server configuration to a private temporary file if it must
+// stay out of argv, returning the file's path, or "" if it is passed via argv. The caller must hold t.mu.
+func (t *SubprocessTransport) writeMcpConfig(options *types.QueryOptions) (string, error) {
+	if options == nil || len(options.McpServers) == 0 {
+		return "", nil
+	}
+	switch t.mcpConfigDelivery {
+	case types.McpConfigDeliveryArgv:
+		return "", nil
+	case types.McpConfigDeliveryFile:
+	default:
+		if !hasMcpCredentials(options.McpServers) {
+			return "", nil
+		}
+	}
+	return t.writeTempFile("mcp-config-*.json", mcpConfigJSON(options.McpServers))
+}
+
+// writeTempFile writes data to a new temporary file readable only by the current user
+// and registers it for removal on Close. The caller must hold t.mu.
+func (t *SubprocessTransport) writeTempFile(pattern string, data []byte) (string, error) {
+	// os.CreateTemp creates files with mode 0600
+	file, err := os.CreateTemp("", "claude-code-sdk-go-"+pattern)
+	if err != nil {
+		return "", errors.NewCLIConnectionError("failed to create temporary file", err)
+	}
+	t.tempFiles = append(t.tempFiles, file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return "", errors.NewCLIConnectionError("failed to write temporary file", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", errors.NewCLIConnectionError("failed to write temporary file", err)
+	}
+	return file.Name(), nil
+}
+
+// removeTempFiles deletes the temporary files created for this connection. The caller must hold t.mu.
+func (t *SubprocessTransport) removeTempFiles() {
+	for _, path := range t.tempFiles {
+		os.Remove(path)
+	}
+	t.tempFiles = nil
+}
+
+// checkFeatures rejects options that the connected CLI cannot honor and that would change
+// the meaning of the query if silently dropped. The caller must hold t.mu.
+func (t *SubprocessTransport) checkFeatures(options *types.QueryOptions) error {
+	if options == nil {
+		return nil
+	}
+	if options.SettingSources != nil && !t.features.SettingSources {
+		return t.unsupportedOption("SettingSources", cli.SettingSourcesVersion)
+	}
+	if len(options.Agents) > 0 && !t.features.Agents {
+		return t.unsupportedOption("Agents", cli.AgentsVersion)
+	}
+	return nil
+}
+
+func (t *SubprocessTransport) unsupportedOption(option string, requiredVersion string) error {
+	return errors.NewCLIVersionError(
+		fmt.Sprintf("QueryOptions.%s requires Claude Code CLI %s or newer (found %s)", option, requiredVersion, t.version),
+		t.cliPath, t.version.String(), requiredVersion, nil)
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// writePrompt sends content as a single stream-json user message and closes stdin,
+// which tells the CLI that no further input will follow.
+func writePrompt(stdin io.WriteCloser, content any) {
+	defer stdin.Close()
+
+	message := map[string]any{
+		"type": "user",
+		"message": map[string]any{
+			"role":    "user",
+			"content": content,
+		},
+		"parent_tool_use_id": nil,
+		"session_id":         "default",
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+	// A failed write means the CLI exited early; its exit status is reported by ReceiveMessages
+	stdin.Write(append(data, '\n'))
+}
+
+func (t *SubprocessTransport) buildCommand(options *types.QueryOptions, plan promptPlan) []string {
 	args := []string{"--output-format", "stream-json", "--verbose"}
 
 	if options != nil {
-		if options.SystemPrompt != "" {
+		if plan.systemPromptFile != "" {
+			args = append(args, "--system-prompt-file", plan.systemPromptFile)
+		} else if options.SystemPrompt != "" {
 			args = append(args, "--system-prompt", options.SystemPrompt)
 		}
 
-		if options.AppendSystemPrompt != "" {
+		if plan.appendSystemPromptFile != "" {
+			args = append(args, "--append-system-prompt-file", plan.appendSystemPromptFile)
+		} else if options.AppendSystemPrompt != "" {
 			args = append(args, "--append-system-prompt", options.AppendSystemPrompt)
 		}
 
-		if len(options.AllowedTools) > 0 {
-			args = append(args, "--allowedTools", strings.Join(options.AllowedTools, ","))
+		// MCP tools are permitted through the same allow list as built-in tools
+		allowedTools := append(slices.Clone(options.AllowedTools), options.McpTools...)
+		if len(allowedTools) > 0 {
+			args = append(args, "--allowedTools", strings.Join(allowedTools, ","))
 		}
 
 		if options.MaxTurns > 0 {
@@ -229,6 +817,14 @@ func (t *SubprocessTransport) buildCommand(options *types.QueryOptions, prompt s
 			args = append(args, "--model", options.Model)
 		}
 
+		if options.FallbackModel != "" {
+			args = append(args, "--fallback-model", options.FallbackModel)
+		}
+
+		if options.MaxThinkingTokens > 0 {
+			args = append(args, "--max-thinking-tokens", fmt.Sprintf("%d", options.MaxThinkingTokens))
+		}
+
 		if options.PermissionPromptToolName != "" {
 			args = append(args, "--permission-prompt-tool", options.PermissionPromptToolName)
 		}
@@ -245,71 +841,136 @@ func (t *SubprocessTransport) buildCommand(options *types.QueryOptions, prompt s
 			args = append(args, "--resume", options.Resume)
 		}
 
-		if len(options.McpServers) > 0 {
-			mcpConfig := map[string]any{
-				"mcpServers": options.McpServers,
+		if options.IncludePartialMessages && t.features.PartialMessages {
+			args = append(args, "--include-partial-messages")
+		}
+
+		if plan.mcpConfigFile != "" {
+			args = append(args, "--mcp-config", plan.mcpConfigFile)
+		} else if len(options.McpServers) > 0 {
+			args = append(args, "--mcp-config", string(mcpConfigJSON(options.McpServers)))
+		}
+
+		if options.StrictMcpConfig {
+			args = append(args, "--strict-mcp-config")
+		}
+
+		for _, dir := range options.AddDirs {
+			args = append(args, "--add-dir", dir)
+		}
+
+		if options.Settings != "" {
+			args = append(args, "--settings", options.Settings)
+		}
+
+		if options.SettingSources != nil {
+			sources := make([]string, len(options.SettingSources))
+			for i, source := range options.SettingSources {
+				sources[i] = string(source)
 			}
-			configJSON, _ := json.Marshal(mcpConfig)
-			args = append(args, "--mcp-config", string(configJSON))
+			args = append(args, "--setting-sources", strings.Join(sources, ","))
+		}
+
+		if len(options.Agents) > 0 {
+			agentsJSON, _ := json.Marshal(options.Agents)
+			args = append(args, "--agents", string(agentsJSON))
 		}
 	}
 
 	// Add the prompt
-	args = append(args, "--print", prompt)
+	if plan.stdin {
+		args = append(args, "--input-format", "stream-json", "--print")
+	} else {
+		args = append(args, "--print", plan.text)
+	}
 
 	return args
 }
 
-func (t *SubprocessTransport) handleProcessCompletion(ctx context.Context, messageCh chan<- types.Message) {
-	// Read stderr with safety limits
-	var stderrLines []string
-	var stderrSize int
-
-	if t.stderr != nil {
-		stderrChan := make(chan string, 100)
-		go func() {
-			defer close(stderrChan)
-			scanner := bufio.NewScanner(t.stderr)
-			for scanner.Scan() {
-				select {
-				case stderrChan <- scanner.Text():
-				case <-ctx.Done():
-					return
-				}
+// stderrCollector reads stderr concurrently with stdout so that a chatty CLI cannot block
+// on a full stderr pipe.
+type stderrCollector struct {
+	mu    sync.Mutex
+	lines []string
+	size  int
+	// done is closed once stderr has been read to the end.
+	done chan struct{}
+}
+
+// collectStderr starts reading stderr until it is closed, keeping at most maxStderrSize bytes.
+// Each line is logged to logger. onTruncate, if not nil, is called when output is dropped.
+func collectStderr(stderr io.Reader, logger *slog.Logger, onTruncate func()) *stderrCollector {
+	c := &stderrCollector{done: make(chan struct{})}
+
+	go func() {
+		defer close(c.done)
+		scanner := bufio.NewScanner(stderr)
+		truncated := false
+		for scanner.Scan() {
+			// Keep draining after truncation so the CLI never blocks on stderr
+			if truncated {
+				continue
+			}
+			line := scanner.Text()
+			logger.Debug("CLI stderr", "line", line)
+
+			c.mu.Lock()
+			if c.size+len(line) > maxStderrSize {
+				c.lines = append(c.lines, fmt.Sprintf("[stderr truncated after %d bytes]", c.size))
+				truncated = true
+			} else {
+				c.lines = append(c.lines, line)
+				c.size += len(line)
 			}
-		}()
+			c.mu.Unlock()
 
-		// Collect stderr with timeout
-		timeout := time.After(stderrTimeout)
-		for {
-			select {
-			case line, ok := <-stderrChan:
-				if !ok {
-					goto waitForProcess
-				}
-				lineSize := len(line)
-				if stderrSize+lineSize > maxStderrSize {
-					stderrLines = append(stderrLines, fmt.Sprintf("[stderr truncated after %d bytes]", stderrSize))
-					// Drain remaining lines
-					for range stderrChan {
-					}
-					goto waitForProcess
+			if truncated {
+				logger.Warn("CLI stderr exceeded the size limit and was truncated", "limit", maxStderrSize)
+				if onTruncate != nil {
+					onTruncate()
 				}
-				stderrLines = append(stderrLines, line)
-				stderrSize += lineSize
-			case <-timeout:
-				stderrLines = append(stderrLines, fmt.Sprintf("[stderr collection timed out after %v]", stderrTimeout))
-				goto waitForProcess
-			case <-ctx.Done():
-				return
 			}
 		}
+	}()
+
+	return c
+}
+
+// output waits for stderr to be closed and returns everything collected. Processes that
+// leave stderr open, e.g. through a surviving child process, are waited for at most
+// stderrTimeout. It returns false if ctx ends first.
+func (c *stderrCollector) output(ctx context.Context) (string, bool) {
+	timedOut := false
+	select {
+	case <-c.done:
+	case <-time.After(stderrTimeout):
+		timedOut = true
+	case <-ctx.Done():
+		return "", false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lines := c.lines
+	if timedOut {
+		lines = append(slices.Clone(lines), fmt.Sprintf("[stderr collection timed out after %v]", stderrTimeout))
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+func (t *SubprocessTransport) handleProcessCompletion(ctx context.Context, send func(types.Message) bool, stderr *stderrCollector) {
+	stderrOutput, ok := stderr.output(ctx)
+	if !ok {
+		return
 	}
 
-waitForProcess:
+	t.mu.Lock()
+	cmd := t.cmd
+	t.mu.Unlock()
+
 	// Wait for process to complete
 	var exitCode int
-	if err := t.cmd.Wait(); err != nil {
+	if err := t.wait(cmd); err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
 				exitCode = status.ExitStatus()
@@ -319,67 +980,21 @@ waitForProcess:
 		}
 	}
 
-	stderrOutput := strings.Join(stderrLines, "\n")
-
-	// Send error message if process failed
-	if exitCode != 0 {
-		errorMsg := types.NewUserMessage(
-			fmt.Sprintf("Process failed with exit code %d: %s", exitCode, stderrOutput))
-		select {
-		case messageCh <- errorMsg:
-		case <-ctx.Done():
-		}
+	// A process killed by Close is not a failure worth reporting
+	if t.isClosing() {
+		t.logger.Debug("CLI process stopped", "exit_code", exitCode)
+		return
 	}
-}
-
-func (t *SubprocessTransport) cleanup() error {
-	var firstErr error
 
-	if t.stdin != nil {
-		if err := t.stdin.Close(); err != nil && firstErr == nil {
-			firstErr = err
-		}
-		t.stdin = nil
-	}
-
-	if t.stdout != nil {
-		if err := t.stdout.Close(); err != nil && firstErr == nil {
-			firstErr = err
-		}
-		t.stdout = nil
-	}
-
-	if t.stderr != nil {
-		if err := t.stderr.Close(); err != nil && firstErr == nil {
-			firstErr = err
-		}
-		t.stderr = nil
-	}
-
-	if t.cmd != nil && t.cmd.Process != nil {
-		// Try graceful termination first
-		if err := t.cmd.Process.Signal(os.Interrupt); err == nil {
-			// Wait a bit for graceful shutdown
-			done := make(chan error, 1)
-			go func() {
-				done <- t.cmd.Wait()
-			}()
-
-			select {
-			case <-done:
-				// Process terminated gracefully
-			case <-time.After(5 * time.Second):
-				// Force kill
-				t.cmd.Process.Kill()
-				<-done
-			}
-		} else {
-			// Force kill immediately
-			t.cmd.Process.Kill()
-			t.cmd.Wait()
-		}
-		t.cmd = nil
+	if exitCode == 0 {
+		t.logger.Debug("CLI process exited", "exit_code", exitCode)
+		return
 	}
 
-	return firstErr
+	// Send error message if process failed. The logger scrubs the record itself.
+	t.logger.Warn("CLI process failed", "exit_code", exitCode, "stderr", stderrOutput)
+	stderrOutput = t.redactor.String(stderrOutput)
+	errorMsg := types.NewErrorMessage(errors.NewProcessError(
+		fmt.Sprintf("Process failed with exit code %d: %s", exitCode, stderrOutput), exitCode, stderrOutput, nil))
+	send(errorMsg)
 }
@@ -0,0 +1,932 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/cli"
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+	"github.com/musaprg/claude-code-sdk-go/internal/redact"
+	"github.com/musaprg/claude-code-sdk-go/internal/types"
+)
+
+// fakeCLIVersion is reported by fake CLIs in response to --version.
+const fakeCLIVersion = "2.0.0"
+
+// writeFakeCLI writes an executable shell script standing in for the Claude Code CLI and returns its path.
+// The script answers --version with fakeCLIVersion and otherwise runs body.
+func writeFakeCLI(t *testing.T, body string) string {
+	t.Helper()
+	return writeFakeCLIWithVersion(t, fakeCLIVersion, body)
+}
+
+func writeFakeCLIWithVersion(t *testing.T, version string, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fake-claude")
+	script := "#!/bin/sh\n" +
+		`if [ "$1" = "--version" ]; then echo "` + version + ` (Claude Code)"; exit 0; fi` + "\n" +
+		body + "\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write fake CLI: %v", err)
+	}
+	return path
+}
+
+const fakeConversation = `echo '{"type":"system","subtype":"init","session_id":"s1"}'
+echo '{"type":"assistant","message":{"content":[{"type":"text","text":"hello"}]}}'
+echo '{"type":"result","subtype":"success","duration_ms":10,"duration_api_ms":5,"is_error":false,"num_turns":1,"session_id":"s1"}'`
+
+func textPrompt(text string) *types.Prompt {
+	return types.NewPrompt().Text(text)
+}
+
+func collect(ch <-chan types.Message) []types.Message {
+	var messages []types.Message
+	for message := range ch {
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+func TestSubprocessTransportReceiveMessages(t *testing.T) {
+	cliPath := writeFakeCLI(t, fakeConversation)
+	transport := NewSubprocessTransport(Config{CLIPath: cliPath})
+	defer transport.Close()
+
+	ctx := context.Background()
+	if err := transport.Connect(ctx, nil, textPrompt("hi")); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	messageCh, err := transport.ReceiveMessages(ctx)
+	if err != nil {
+		t.Fatalf("ReceiveMessages() error = %v", err)
+	}
+
+	messages := collect(messageCh)
+	want := []types.MessageType{types.MessageTypeSystem, types.MessageTypeAssistant, types.MessageTypeResult}
+	if len(messages) != len(want) {
+		t.Fatalf("received %d messages, want %d: %v", len(messages), len(want), messages)
+	}
+	for i, message := range messages {
+		if message.Type() != want[i] {
+			t.Errorf("messages[%d].Type() = %v, want %v", i, message.Type(), want[i])
+		}
+	}
+}
+
+func TestSubprocessTransportProcessFailure(t *testing.T) {
+	cliPath := writeFakeCLI(t, `echo "boom" >&2
+exit 3`)
+	transport := NewSubprocessTransport(Config{CLIPath: cliPath})
+	defer transport.Close()
+
+	ctx := context.Background()
+	if err := transport.Connect(ctx, nil, textPrompt("hi")); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	messageCh, err := transport.ReceiveMessages(ctx)
+	if err != nil {
+		t.Fatalf("ReceiveMessages() error = %v", err)
+	}
+
+	messages := collect(messageCh)
+	if len(messages) != 1 {
+		t.Fatalf("received %d messages, want 1", len(messages))
+	}
+	errorMsg, ok := messages[0].(*types.ErrorMessage)
+	if !ok {
+		t.Fatalf("messages[0] = %T, want *types.ErrorMessage", messages[0])
+	}
+	var processErr *errors.ProcessError
+	if !stderrors.As(errorMsg, &processErr) {
+		t.Fatalf("ErrorMessage.Err = %T, want *errors.ProcessError", errorMsg.Err)
+	}
+	if processErr.ExitCode != 3 || !strings.Contains(processErr.Stderr, "boom") {
+		t.Errorf("ProcessError = (%d, %q), want exit code 3 and stderr", processErr.ExitCode, processErr.Stderr)
+	}
+}
+
+func TestSubprocessTransportStateTransitions(t *testing.T) {
+	cliPath := writeFakeCLI(t, fakeConversation)
+	ctx := context.Background()
+
+	t.Run("receive before connect", func(t *testing.T) {
+		transport := NewSubprocessTransport(Config{CLIPath: cliPath})
+		if _, err := transport.ReceiveMessages(ctx); err == nil {
+			t.Error("ReceiveMessages() before Connect() should fail")
+		}
+	})
+
+	t.Run("connect twice", func(t *testing.T) {
+		transport := NewSubprocessTransport(Config{CLIPath: cliPath})
+		defer transport.Close()
+		if err := transport.Connect(ctx, nil, textPrompt("hi")); err != nil {
+			t.Fatalf("Connect() error = %v", err)
+		}
+		if err := transport.Connect(ctx, nil, textPrompt("hi")); err == nil {
+			t.Error("second Connect() should fail")
+		}
+	})
+
+	t.Run("receive twice", func(t *testing.T) {
+		transport := NewSubprocessTransport(Config{CLIPath: cliPath})
+		defer transport.Close()
+		if err := transport.Connect(ctx, nil, textPrompt("hi")); err != nil {
+			t.Fatalf("Connect() error = %v", err)
+		}
+		messageCh, err := transport.ReceiveMessages(ctx)
+		if err != nil {
+			t.Fatalf("ReceiveMessages() error = %v", err)
+		}
+		if _, err := transport.ReceiveMessages(ctx); err == nil {
+			t.Error("second ReceiveMessages() should fail")
+		}
+		collect(messageCh)
+	})
+
+	t.Run("connect after close", func(t *testing.T) {
+		transport := NewSubprocessTransport(Config{CLIPath: cliPath})
+		if err := transport.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		if err := transport.Connect(ctx, nil, textPrompt("hi")); err == nil {
+			t.Error("Connect() after Close() should fail")
+		}
+	})
+
+	t.Run("failed connect", func(t *testing.T) {
+		transport := NewSubprocessTransport(Config{CLIPath: cliPath, CWD: filepath.Join(t.TempDir(), "missing")})
+		if err := transport.Connect(ctx, nil, textPrompt("hi")); err == nil {
+			t.Fatal("Connect() with missing working directory should fail")
+		}
+		if err := transport.Close(); err != nil {
+			t.Errorf("Close() after failed Connect() error = %v", err)
+		}
+	})
+}
+
+func TestSubprocessTransportConcurrentClose(t *testing.T) {
+	// The fake CLI emits one message and then hangs until it is terminated.
+	cliPath := writeFakeCLI(t, `echo '{"type":"system","subtype":"init"}'
+exec sleep 30`)
+	transport := NewSubprocessTransport(Config{CLIPath: cliPath})
+
+	ctx := context.Background()
+	if err := transport.Connect(ctx, nil, textPrompt("hi")); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	messageCh, err := transport.ReceiveMessages(ctx)
+	if err != nil {
+		t.Fatalf("ReceiveMessages() error = %v", err)
+	}
+
+	select {
+	case <-messageCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for first message")
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := transport.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case _, ok := <-messageCh:
+		for ok {
+			_, ok = <-messageCh
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("message channel was not closed after Close()")
+	}
+
+	if err := transport.Close(); err != nil {
+		t.Errorf("Close() after Close() error = %v", err)
+	}
+}
+
+func TestSubprocessTransportCloseWhileConnecting(t *testing.T) {
+	dir := t.TempDir()
+	started := filepath.Join(dir, "started")
+	cliPath := filepath.Join(dir, "fake-claude")
+	// The version check blocks until the test lets it finish
+	script := "#!/bin/sh\n" +
+		`if [ "$1" = "--version" ]; then while [ ! -f "` + dir + `/release" ]; do sleep 0.01; done; echo "` + fakeCLIVersion + ` (Claude Code)"; exit 0; fi` + "\n" +
+		`touch "` + started + `"` + "\n"
+	if err := os.WriteFile(cliPath, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	transport := NewSubprocessTransport(Config{CLIPath: cliPath})
+
+	connectErr := make(chan error, 1)
+	go func() { connectErr <- transport.Connect(context.Background(), nil, textPrompt("hi")) }()
+	for {
+		transport.mu.Lock()
+		connecting := transport.state == stateConnecting
+		transport.mu.Unlock()
+		if connecting {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		transport.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() waited for the version check")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "release"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-connectErr; err == nil {
+		t.Error("Connect() succeeded on a transport closed while connecting")
+	}
+	if _, err := os.Stat(started); !os.IsNotExist(err) {
+		t.Error("the CLI was started after the transport was closed")
+	}
+}
+
+func TestSubprocessTransportContextCancel(t *testing.T) {
+	cliPath := writeFakeCLI(t, `exec sleep 30`)
+	transport := NewSubprocessTransport(Config{CLIPath: cliPath})
+	defer transport.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := transport.Connect(ctx, nil, textPrompt("hi")); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	messageCh, err := transport.ReceiveMessages(ctx)
+	if err != nil {
+		t.Fatalf("ReceiveMessages() error = %v", err)
+	}
+
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		collect(messageCh)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("message channel was not closed after context cancellation")
+	}
+}
+
+func TestPromptDelivery(t *testing.T) {
+	longPrompt := strings.Repeat("x", maxArgvPromptSize+1)
+
+	tests := []struct {
+		name      string
+		delivery  types.PromptDelivery
+		prompt    string
+		wantStdin bool
+	}{
+		{name: "auto short prompt", delivery: "", prompt: "hi", wantStdin: false},
+		{name: "auto long prompt", delivery: types.PromptDeliveryAuto, prompt: longPrompt, wantStdin: true},
+		{name: "argv long prompt", delivery: types.PromptDeliveryArgv, prompt: longPrompt, wantStdin: false},
+		{name: "stdin short prompt", delivery: types.PromptDeliveryStdin, prompt: "hi", wantStdin: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cliPath := writeFakeCLI(t, `printf '%s\n' "$@" > "`+dir+`/args"
+cat > "`+dir+`/stdin"
+`+fakeConversation)
+			transport := NewSubprocessTransport(Config{CLIPath: cliPath, PromptDelivery: tt.delivery})
+			defer transport.Close()
+
+			ctx := context.Background()
+			if err := transport.Connect(ctx, nil, textPrompt(tt.prompt)); err != nil {
+				t.Fatalf("Connect() error = %v", err)
+			}
+			messageCh, err := transport.ReceiveMessages(ctx)
+			if err != nil {
+				t.Fatalf("ReceiveMessages() error = %v", err)
+			}
+			collect(messageCh)
+
+			args, err := os.ReadFile(filepath.Join(dir, "args"))
+			if err != nil {
+				t.Fatalf("failed to read recorded args: %v", err)
+			}
+			stdin, err := os.ReadFile(filepath.Join(dir, "stdin"))
+			if err != nil {
+				t.Fatalf("failed to read recorded stdin: %v", err)
+			}
+
+			if got := strings.Contains(string(args), tt.prompt); got == tt.wantStdin {
+				t.Errorf("prompt in argv = %v, want %v", got, !tt.wantStdin)
+			}
+			if got := strings.Contains(string(args), "--input-format\nstream-json"); got != tt.wantStdin {
+				t.Errorf("--input-format stream-json in argv = %v, want %v", got, tt.wantStdin)
+			}
+
+			if !tt.wantStdin {
+				if len(stdin) != 0 {
+					t.Errorf("stdin = %q, want empty", stdin)
+				}
+				return
+			}
+			var message struct {
+				Type    string `json:"type"`
+				Message struct {
+					Role    string `json:"role"`
+					Content string `json:"content"`
+				} `json:"message"`
+			}
+			if err := json.Unmarshal(stdin, &message); err != nil {
+				t.Fatalf("stdin is not a stream-json message: %v", err)
+			}
+			if message.Type != "user" || message.Message.Role != "user" || message.Message.Content != tt.prompt {
+				t.Errorf("stdin message = %+v, want user message with prompt", message)
+			}
+		})
+	}
+}
+
+func TestSubprocessTransportSpawn(t *testing.T) {
+	dir := t.TempDir()
+	// The fake CLI answers once the prompt arrives on stdin
+	cliPath := writeFakeCLI(t, `read -r line
+printf '%s\n' "$line" > "`+dir+`/stdin"
+`+fakeConversation)
+	transport := NewSubprocessTransport(Config{CLIPath: cliPath})
+	defer transport.Close()
+
+	ctx := context.Background()
+	if err := transport.Spawn(ctx, nil); err != nil {
+		t.Fatalf("Spawn() error = %v", err)
+	}
+	messageCh, err := transport.ReceiveMessages(ctx)
+	if err != nil {
+		t.Fatalf("ReceiveMessages() error = %v", err)
+	}
+
+	// The process stays up while it waits for its prompt
+	time.Sleep(50 * time.Millisecond)
+	if transport.Exited() {
+		t.Fatal("Exited() = true before the prompt was sent")
+	}
+
+	if err := transport.Send(textPrompt("warm hello")); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if err := transport.Send(textPrompt("again")); err == nil {
+		t.Error("second Send() should fail")
+	}
+
+	if messages := collect(messageCh); len(messages) != 3 {
+		t.Errorf("got %d messages, want 3", len(messages))
+	}
+	if !transport.Exited() {
+		t.Error("Exited() = false after the process finished")
+	}
+	stdin, err := os.ReadFile(filepath.Join(dir, "stdin"))
+	if err != nil || !strings.Contains(string(stdin), `"content":"warm hello"`) {
+		t.Errorf("stdin = %q, %v; want the prompt as a stream-json message", stdin, err)
+	}
+
+	connected := NewSubprocessTransport(Config{CLIPath: cliPath})
+	defer connected.Close()
+	if err := connected.Connect(ctx, nil, textPrompt("hi")); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if err := connected.Send(textPrompt("hi")); err == nil {
+		t.Error("Send() on a transport started by Connect should fail")
+	}
+}
+
+func TestSystemPromptFiles(t *testing.T) {
+	transport := NewSubprocessTransport(Config{PromptDelivery: types.PromptDeliveryStdin})
+	options := &types.QueryOptions{
+		SystemPrompt:       "secret system prompt",
+		AppendSystemPrompt: "secret appendix",
+	}
+
+	plan, err := transport.planPrompt(options, textPrompt("hi"))
+	if err != nil {
+		t.Fatalf("planPrompt() error = %v", err)
+	}
+	args := strings.Join(transport.buildCommand(options, plan), " ")
+	if strings.Contains(args, "secret") {
+		t.Errorf("argv contains system prompt: %s", args)
+	}
+
+	for flag, path := range map[string]string{
+		"--system-prompt-file":        plan.systemPromptFile,
+		"--append-system-prompt-file": plan.appendSystemPromptFile,
+	} {
+		if !strings.Contains(args, flag+" "+path) {
+			t.Errorf("argv = %s, want %s %s", args, flag, path)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("system prompt file missing: %v", err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("system prompt file mode = %v, want 0600", perm)
+		}
+	}
+
+	transport.Close()
+	for _, path := range []string{plan.systemPromptFile, plan.appendSystemPromptFile} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("temporary file %s was not removed on Close()", path)
+		}
+	}
+}
+
+func TestMcpConfigDelivery(t *testing.T) {
+	plain := map[string]types.McpServerConfig{"fs": types.McpStdioServer{Command: "fs-mcp"}}
+	withEnv := map[string]types.McpServerConfig{
+		"github": types.McpStdioServer{Command: "github-mcp", Env: map[string]string{"GITHUB_TOKEN": "ghp_secret_value"}},
+	}
+	tests := []struct {
+		name     string
+		delivery types.McpConfigDelivery
+		servers  map[string]types.McpServerConfig
+		wantFile bool
+	}{
+		{name: "auto without credentials", servers: plain},
+		{name: "auto with credentials", servers: withEnv, wantFile: true},
+		{name: "argv", delivery: types.McpConfigDeliveryArgv, servers: withEnv},
+		{name: "file", delivery: types.McpConfigDeliveryFile, servers: plain, wantFile: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := NewSubprocessTransport(Config{McpConfigDelivery: tt.delivery})
+			options := &types.QueryOptions{McpServers: tt.servers}
+
+			var plan promptPlan
+			var err error
+			if plan.mcpConfigFile, err = transport.writeMcpConfig(options); err != nil {
+				t.Fatalf("writeMcpConfig() error = %v", err)
+			}
+			args := transport.buildCommand(options, plan)
+			i := slices.Index(args, "--mcp-config")
+			if i < 0 {
+				t.Fatalf("argv = %q, want --mcp-config", args)
+			}
+			config := args[i+1]
+
+			if !tt.wantFile {
+				if plan.mcpConfigFile != "" || !json.Valid([]byte(config)) {
+					t.Errorf("--mcp-config = %q, want inline JSON", config)
+				}
+				return
+			}
+			if config != plan.mcpConfigFile {
+				t.Fatalf("--mcp-config = %q, want %q", config, plan.mcpConfigFile)
+			}
+			info, err := os.Stat(config)
+			if err != nil {
+				t.Fatalf("MCP config file missing: %v", err)
+			}
+			if perm := info.Mode().Perm(); perm != 0o600 {
+				t.Errorf("MCP config file mode = %v, want 0600", perm)
+			}
+			data, _ := os.ReadFile(config)
+			if string(data) != string(mcpConfigJSON(tt.servers)) {
+				t.Errorf("MCP config file = %s, want %s", data, mcpConfigJSON(tt.servers))
+			}
+
+			transport.Close()
+			if _, err := os.Stat(config); !os.IsNotExist(err) {
+				t.Errorf("MCP config file %s was not removed on Close()", config)
+			}
+		})
+	}
+}
+
+func TestProcessErrorRedacted(t *testing.T) {
+	cliPath := writeFakeCLI(t, `echo "auth failed for ghp_secret_value with sk-ant-REDACTED" >&2
+exit 1`)
+	transport := NewSubprocessTransport(Config{CLIPath: cliPath, Redactor: redact.New(nil)})
+	defer transport.Close()
+
+	options := &types.QueryOptions{McpServers: map[string]types.McpServerConfig{
+		"github": types.McpStdioServer{Command: "github-mcp", Env: map[string]string{"GITHUB_TOKEN": "ghp_secret_value"}},
+	}}
+	ctx := context.Background()
+	if err := transport.Connect(ctx, options, textPrompt("hi")); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	messageCh, err := transport.ReceiveMessages(ctx)
+	if err != nil {
+		t.Fatalf("ReceiveMessages() error = %v", err)
+	}
+
+	messages := collect(messageCh)
+	var processErr *errors.ProcessError
+	if len(messages) != 1 || !stderrors.As(messages[0].(*types.ErrorMessage), &processErr) {
+		t.Fatalf("messages = %v, want a single ProcessError", messages)
+	}
+	for _, text := range []string{processErr.Error(), processErr.Stderr} {
+		if strings.Contains(text, "ghp_secret_value") || strings.Contains(text, "sk-ant-") {
+			t.Errorf("ProcessError contains a secret: %q", text)
+		}
+		if !strings.Contains(text, "auth failed for [REDACTED] with [REDACTED]") {
+			t.Errorf("ProcessError = %q, want redacted secrets", text)
+		}
+	}
+}
+
+func TestMultimodalPromptUsesStdin(t *testing.T) {
+	dir := t.TempDir()
+	cliPath := writeFakeCLI(t, `printf '%s\n' "$@" > "`+dir+`/args"
+cat > "`+dir+`/stdin"
+`+fakeConversation)
+	// Even when argv delivery is requested, images can only be sent over stdin
+	transport := NewSubprocessTransport(Config{CLIPath: cliPath, PromptDelivery: types.PromptDeliveryArgv})
+	defer transport.Close()
+
+	prompt := types.NewPrompt().Text("describe").ImageBytes([]byte("png"), types.MediaTypePNG)
+	ctx := context.Background()
+	if err := transport.Connect(ctx, nil, prompt); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	messageCh, err := transport.ReceiveMessages(ctx)
+	if err != nil {
+		t.Fatalf("ReceiveMessages() error = %v", err)
+	}
+	collect(messageCh)
+
+	args, err := os.ReadFile(filepath.Join(dir, "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(args), "describe") {
+		t.Errorf("argv contains prompt text: %q", args)
+	}
+
+	stdin, err := os.ReadFile(filepath.Join(dir, "stdin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var message struct {
+		Message struct {
+			Content []map[string]any `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(stdin, &message); err != nil {
+		t.Fatalf("stdin is not a stream-json message: %v", err)
+	}
+	if len(message.Message.Content) != 2 || message.Message.Content[1]["type"] != "image" {
+		t.Errorf("stdin content = %v, want text and image blocks", message.Message.Content)
+	}
+}
+
+func TestVersionCheck(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("too old", func(t *testing.T) {
+		cliPath := writeFakeCLIWithVersion(t, "0.9.0", fakeConversation)
+		transport := NewSubprocessTransport(Config{CLIPath: cliPath})
+		defer transport.Close()
+
+		err := transport.Connect(ctx, nil, textPrompt("hi"))
+		var versionErr *errors.CLIVersionError
+		if !stderrors.As(err, &versionErr) {
+			t.Fatalf("Connect() error = %v, want *errors.CLIVersionError", err)
+		}
+		if versionErr.Version != "0.9.0" || versionErr.MinimumVersion != cli.MinimumCLIVersion {
+			t.Errorf("CLIVersionError = %+v, want detected 0.9.0 and minimum %s", versionErr, cli.MinimumCLIVersion)
+		}
+	})
+
+	t.Run("skip version check", func(t *testing.T) {
+		cliPath := writeFakeCLIWithVersion(t, "0.9.0", fakeConversation)
+		transport := NewSubprocessTransport(Config{CLIPath: cliPath, SkipVersionCheck: true})
+		defer transport.Close()
+
+		if err := transport.Connect(ctx, nil, textPrompt("hi")); err != nil {
+			t.Fatalf("Connect() error = %v", err)
+		}
+	})
+
+	t.Run("features degrade on older CLI", func(t *testing.T) {
+		dir := t.TempDir()
+		cliPath := writeFakeCLIWithVersion(t, "1.0.61", `printf '%s\n' "$@" > "`+dir+`/args"
+`+fakeConversation)
+		transport := NewSubprocessTransport(Config{CLIPath: cliPath, PromptDelivery: types.PromptDeliveryStdin})
+		defer transport.Close()
+
+		options := &types.QueryOptions{SystemPrompt: "be brief", IncludePartialMessages: true}
+		if err := transport.Connect(ctx, options, textPrompt("hi")); err != nil {
+			t.Fatalf("Connect() error = %v", err)
+		}
+		messageCh, err := transport.ReceiveMessages(ctx)
+		if err != nil {
+			t.Fatalf("ReceiveMessages() error = %v", err)
+		}
+		collect(messageCh)
+
+		args, err := os.ReadFile(filepath.Join(dir, "args"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(args), "--include-partial-messages") {
+			t.Errorf("argv = %q, want no --include-partial-messages for CLI 1.0.61", args)
+		}
+	})
+}
+
+func TestScriptCLIRunsThroughNode(t *testing.T) {
+	dir := t.TempDir()
+	// The fake node binary records that it was used and runs the script with sh
+	nodePath := filepath.Join(dir, "node")
+	nodeScript := "#!/bin/sh\ntouch \"" + dir + "/node-used\"\nscript=\"$1\"\nshift\nexec sh \"$script\" \"$@\"\n"
+	if err := os.WriteFile(nodePath, []byte(nodeScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cliScript := writeFakeCLI(t, fakeConversation)
+	cliPath := filepath.Join(dir, "cli.js")
+	if err := os.Rename(cliScript, cliPath); err != nil {
+		t.Fatal(err)
+	}
+
+	transport := NewSubprocessTransport(Config{CLIPath: cliPath, NodePath: nodePath})
+	defer transport.Close()
+
+	ctx := context.Background()
+	if err := transport.Connect(ctx, nil, textPrompt("hi")); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	messageCh, err := transport.ReceiveMessages(ctx)
+	if err != nil {
+		t.Fatalf("ReceiveMessages() error = %v", err)
+	}
+	if messages := collect(messageCh); len(messages) != 3 {
+		t.Errorf("received %d messages, want 3", len(messages))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "node-used")); err != nil {
+		t.Errorf("cli.js was not run through the configured node binary: %v", err)
+	}
+}
+
+func TestBuildCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		options *types.QueryOptions
+		want    []string
+	}{
+		{
+			name:    "no options",
+			options: nil,
+			want:    []string{"--output-format", "stream-json", "--verbose", "--print", "hi"},
+		},
+		{
+			name:    "allowed and mcp tools",
+			options: &types.QueryOptions{AllowedTools: []string{"Read"}, McpTools: []string{"mcp__github__get_issue"}},
+			want:    []string{"--allowedTools", "Read,mcp__github__get_issue"},
+		},
+		{
+			name:    "mcp tools only",
+			options: &types.QueryOptions{McpTools: []string{"mcp__github__get_issue"}},
+			want:    []string{"--allowedTools", "mcp__github__get_issue"},
+		},
+		{
+			name:    "max thinking tokens",
+			options: &types.QueryOptions{MaxThinkingTokens: 8000},
+			want:    []string{"--max-thinking-tokens", "8000"},
+		},
+		{
+			name:    "fallback model",
+			options: &types.QueryOptions{Model: "opus", FallbackModel: "sonnet"},
+			want:    []string{"--model", "opus", "--fallback-model", "sonnet"},
+		},
+		{
+			name:    "add dirs",
+			options: &types.QueryOptions{AddDirs: []string{"/data", "/logs"}},
+			want:    []string{"--add-dir", "/data", "--add-dir", "/logs"},
+		},
+		{
+			name:    "settings",
+			options: &types.QueryOptions{Settings: `{"model":"opus"}`},
+			want:    []string{"--settings", `{"model":"opus"}`},
+		},
+		{
+			name:    "setting sources",
+			options: &types.QueryOptions{SettingSources: []types.SettingSource{types.SettingSourceUser, types.SettingSourceProject}},
+			want:    []string{"--setting-sources", "user,project"},
+		},
+		{
+			name:    "empty setting sources",
+			options: &types.QueryOptions{SettingSources: []types.SettingSource{}},
+			want:    []string{"--setting-sources", ""},
+		},
+		{
+			name:    "strict mcp config",
+			options: &types.QueryOptions{StrictMcpConfig: true},
+			want:    []string{"--strict-mcp-config"},
+		},
+		{
+			name: "agents",
+			options: &types.QueryOptions{Agents: map[string]types.AgentDefinition{
+				"reviewer": {Description: "Reviews code", Prompt: "You review code.", Tools: []string{"Read"}},
+			}},
+			want: []string{"--agents", `{"reviewer":{"description":"Reviews code","prompt":"You review code.","tools":["Read"]}}`},
+		},
+		{
+			name:    "partial messages",
+			options: &types.QueryOptions{IncludePartialMessages: true},
+			want:    []string{"--include-partial-messages"},
+		},
+		{
+			name:    "continue and resume",
+			options: &types.QueryOptions{ContinueConversation: true, Resume: "session-1"},
+			want:    []string{"--continue", "--resume", "session-1"},
+		},
+		{
+			name:    "permissions",
+			options: &types.QueryOptions{PermissionMode: types.PermissionModeAcceptEdits, PermissionPromptToolName: "mcp__auth__prompt"},
+			want:    []string{"--permission-prompt-tool", "mcp__auth__prompt", "--permission-mode", "acceptEdits"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := NewSubprocessTransport(Config{})
+			plan, err := transport.planPrompt(tt.options, textPrompt("hi"))
+			if err != nil {
+				t.Fatalf("planPrompt() error = %v", err)
+			}
+			args := transport.buildCommand(tt.options, plan)
+			if !containsSequence(args, tt.want) {
+				t.Errorf("buildCommand() = %q, want it to contain %q", args, tt.want)
+			}
+		})
+	}
+}
+
+// containsSequence reports whether want appears as a contiguous subsequence of args.
+func containsSequence(args []string, want []string) bool {
+	for i := 0; i+len(want) <= len(args); i++ {
+		if slices.Equal(args[i:i+len(want)], want) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestQueryOptionsCWD(t *testing.T) {
+	dir := t.TempDir()
+	cliPath := writeFakeCLI(t, `pwd > "`+dir+`/pwd"
+`+fakeConversation)
+	queryDir := t.TempDir()
+	transport := NewSubprocessTransport(Config{CLIPath: cliPath, CWD: t.TempDir()})
+	defer transport.Close()
+
+	ctx := context.Background()
+	if err := transport.Connect(ctx, &types.QueryOptions{CWD: queryDir}, textPrompt("hi")); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	messageCh, err := transport.ReceiveMessages(ctx)
+	if err != nil {
+		t.Fatalf("ReceiveMessages() error = %v", err)
+	}
+	collect(messageCh)
+
+	pwd, err := os.ReadFile(filepath.Join(dir, "pwd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := filepath.EvalSymlinks(queryDir)
+	if got := strings.TrimSpace(string(pwd)); got != want {
+		t.Errorf("CLI working directory = %q, want QueryOptions.CWD %q", got, want)
+	}
+}
+
+func TestQueryOptionsUser(t *testing.T) {
+	cliPath := writeFakeCLI(t, fakeConversation)
+	transport := NewSubprocessTransport(Config{CLIPath: cliPath})
+	defer transport.Close()
+
+	err := transport.Connect(context.Background(), &types.QueryOptions{User: "no-such-user-for-sdk-tests"}, textPrompt("hi"))
+	if err == nil {
+		t.Fatal("Connect() with unknown user should fail")
+	}
+}
+
+func TestUnsupportedOptionsRejected(t *testing.T) {
+	cliPath := writeFakeCLIWithVersion(t, "1.0.90", fakeConversation)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		options *types.QueryOptions
+	}{
+		{name: "agents", options: &types.QueryOptions{Agents: map[string]types.AgentDefinition{"a": {Description: "d", Prompt: "p"}}}},
+		{name: "setting sources", options: &types.QueryOptions{SettingSources: []types.SettingSource{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := NewSubprocessTransport(Config{CLIPath: cliPath})
+			defer transport.Close()
+
+			err := transport.Connect(ctx, tt.options, textPrompt("hi"))
+			var versionErr *errors.CLIVersionError
+			if !stderrors.As(err, &versionErr) {
+				t.Fatalf("Connect() error = %v, want *errors.CLIVersionError", err)
+			}
+			if versionErr.Version != "1.0.90" {
+				t.Errorf("CLIVersionError.Version = %q, want %q", versionErr.Version, "1.0.90")
+			}
+		})
+	}
+}
+
+func TestSubprocessTransportLogging(t *testing.T) {
+	cliPath := writeFakeCLI(t, `echo 'not json'
+echo '{"type":"control_response","response":{}}'
+echo "something broke" >&2
+exit 2`)
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	transport := NewSubprocessTransport(Config{CLIPath: cliPath, Logger: logger})
+	defer transport.Close()
+
+	options := &types.QueryOptions{McpServers: map[string]types.McpServerConfig{
+		"github": types.McpStdioServer{Command: "github-mcp", Env: map[string]string{"GITHUB_TOKEN": "ghp_secret"}},
+	}}
+	ctx := context.Background()
+	if err := transport.Connect(ctx, options, textPrompt("a private prompt")); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	messageCh, err := transport.ReceiveMessages(ctx)
+	if err != nil {
+		t.Fatalf("ReceiveMessages() error = %v", err)
+	}
+	collect(messageCh)
+
+	output := logs.String()
+	for _, want := range []string{
+		"started CLI process",
+		"skipped non-JSON CLI output line",
+		"skipped CLI control response",
+		"something broke",
+		"CLI process failed",
+		"exit_code=2",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("logs do not contain %q:\n%s", want, output)
+		}
+	}
+	for _, secret := range []string{"ghp_secret", "a private prompt"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("logs contain %q:\n%s", secret, output)
+		}
+	}
+}
+
+func TestRedactArgs(t *testing.T) {
+	args := []string{
+		"--output-format", "stream-json",
+		"--system-prompt", "be terse",
+		"--mcp-config", `{"mcpServers":{"api":{"type":"http","url":"https://example.com","headers":{"Authorization":"Bearer abc"}}}}`,
+		"--add-dir", "/srv/acme-internal",
+		"--print", "hello",
+	}
+	got := redactArgs(args, redact.New(&redact.Options{Secrets: []string{"acme-internal"}}))
+
+	want := []string{
+		"--output-format", "stream-json",
+		"--system-prompt", "[8 bytes]",
+		"--mcp-config", `{"mcpServers":{"api":{"headers":{"Authorization":"[REDACTED]"},"type":"http","url":"https://example.com"}}}`,
+		"--add-dir", "/srv/[REDACTED]",
+		"--print", "[5 bytes]",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("redactArgs() = %q, want %q", got, want)
+	}
+	if args[3] != "be terse" {
+		t.Error("redactArgs() modified its input")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package transport
+
+import (
+	"os/exec"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+)
+
+// setUser is not supported on this platform.
+func setUser(cmd *exec.Cmd, name string, files []string) error {
+	return errors.NewCLIConnectionError("QueryOptions.User is only supported on Unix systems", nil)
+}
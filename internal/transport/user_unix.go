@@ -0,0 +1,47 @@
+//go:build unix
+
+package transport
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+)
+
+// setUser configures cmd to run as the given user, identified by name or numeric ID, and
+// hands files the CLI has to read, such as private temporary files, over to that user.
+func setUser(cmd *exec.Cmd, name string, files []string) error {
+	u, err := user.Lookup(name)
+	if err != nil {
+		u, err = user.LookupId(name)
+	}
+	if err != nil {
+		return errors.NewCLIConnectionError(fmt.Sprintf("unknown user: %s", name), err)
+	}
+
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return errors.NewCLIConnectionError(fmt.Sprintf("invalid uid for user %s: %s", name, u.Uid), err)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return errors.NewCLIConnectionError(fmt.Sprintf("invalid gid for user %s: %s", name, u.Gid), err)
+	}
+
+	for _, path := range files {
+		if err := os.Chown(path, int(uid), int(gid)); err != nil {
+			return errors.NewCLIConnectionError(fmt.Sprintf("failed to hand temporary file over to user %s", name), err)
+		}
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+	return nil
+}
@@ -0,0 +1,66 @@
+//go:build unix
+
+package transport
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/types"
+)
+
+func TestQueryOptionsUserReadsTempFiles(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("running as another user requires root")
+	}
+	if _, err := user.Lookup("nobody"); err != nil {
+		t.Skip("no nobody user")
+	}
+
+	// The fake CLI fails unless it can read the files it is given
+	cliPath := writeFakeCLI(t, `while [ $# -gt 0 ]; do
+  case "$1" in --system-prompt-file|--mcp-config) cat "$2" >/dev/null || exit 3;; esac
+  shift
+done
+`+fakeConversation)
+	cwd := t.TempDir()
+	for _, dir := range []string{filepath.Dir(cliPath), filepath.Dir(filepath.Dir(cliPath)), cwd} {
+		if err := os.Chmod(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	transport := NewSubprocessTransport(Config{
+		CLIPath:           cliPath,
+		PromptDelivery:    types.PromptDeliveryStdin,
+		McpConfigDelivery: types.McpConfigDeliveryFile,
+	})
+	defer transport.Close()
+
+	ctx := context.Background()
+	options := &types.QueryOptions{
+		User:         "nobody",
+		CWD:          cwd,
+		SystemPrompt: "secret system prompt",
+		McpServers:   map[string]types.McpServerConfig{"fs": types.McpStdioServer{Command: "fs-mcp"}},
+	}
+	if err := transport.Connect(ctx, options, textPrompt("hi")); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	messageCh, err := transport.ReceiveMessages(ctx)
+	if err != nil {
+		t.Fatalf("ReceiveMessages() error = %v", err)
+	}
+	messages := collect(messageCh)
+	for _, message := range messages {
+		if errorMsg, ok := message.(*types.ErrorMessage); ok {
+			t.Fatalf("CLI running as nobody failed: %v", errorMsg.Err)
+		}
+	}
+	if _, ok := messages[len(messages)-1].(*types.ResultMessage); !ok {
+		t.Errorf("last message = %T, want *types.ResultMessage", messages[len(messages)-1])
+	}
+}
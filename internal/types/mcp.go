@@ -0,0 +1,184 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+)
+
+// McpServerType identifies how Claude Code connects to an MCP server.
+type McpServerType string
+
+const (
+	// McpServerTypeStdio servers are processes started by the CLI that talk over stdin and stdout.
+	McpServerTypeStdio McpServerType = "stdio"
+	// McpServerTypeSSE servers are remote servers reached over HTTP with server-sent events.
+	McpServerTypeSSE McpServerType = "sse"
+	// McpServerTypeHTTP servers are remote servers reached over streamable HTTP.
+	McpServerTypeHTTP McpServerType = "http"
+	// McpServerTypeSDK servers run in the process of an SDK and are reached over the CLI's control protocol.
+	McpServerTypeSDK McpServerType = "sdk"
+)
+
+// McpServerConfig represents configuration for a Model Context Protocol (MCP) server.
+// MCP servers extend Claude Code's capabilities with additional tools and resources.
+// It is one of the values McpStdioServer, McpSSEServer, McpHTTPServer, or McpSDKServer;
+// other types cannot implement it. Each marshals to JSON in the format of the CLI's --mcp-config.
+type McpServerConfig interface {
+	// ServerType returns how Claude Code connects to the server.
+	ServerType() McpServerType
+	// isMcpServerConfig restricts implementations to this package.
+	isMcpServerConfig()
+}
+
+// McpStdioServer is an MCP server process started by the CLI.
+type McpStdioServer struct {
+	// Command is the executable of the server. It is required.
+	Command string `json:"command"`
+	// Args contains command-line arguments for the server executable.
+	Args []string `json:"args,omitempty"`
+	// Env contains environment variables to set for the server process.
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// McpSSEServer is a remote MCP server reached over HTTP with server-sent events.
+type McpSSEServer struct {
+	// URL is the endpoint of the server. It is required.
+	URL string `json:"url"`
+	// Headers contains HTTP headers sent with every request, such as Authorization.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// McpHTTPServer is a remote MCP server reached over streamable HTTP.
+type McpHTTPServer struct {
+	// URL is the endpoint of the server. It is required.
+	URL string `json:"url"`
+	// Headers contains HTTP headers sent with every request, such as Authorization.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// McpSDKServer is an MCP server that runs in the process of an SDK. The CLI forwards its
+// requests over the control protocol, which this SDK does not serve yet, so
+// QueryOptions.Validate rejects it; it is recognized so that configurations containing
+// SDK servers can be parsed.
+type McpSDKServer struct {
+	// Name identifies the server to the SDK that hosts it. It is required.
+	Name string `json:"name"`
+}
+
+func (McpStdioServer) ServerType() McpServerType { return McpServerTypeStdio }
+func (McpSSEServer) ServerType() McpServerType   { return McpServerTypeSSE }
+func (McpHTTPServer) ServerType() McpServerType  { return McpServerTypeHTTP }
+func (McpSDKServer) ServerType() McpServerType   { return McpServerTypeSDK }
+
+func (McpStdioServer) isMcpServerConfig() {}
+func (McpSSEServer) isMcpServerConfig()   {}
+func (McpHTTPServer) isMcpServerConfig()  {}
+func (McpSDKServer) isMcpServerConfig()   {}
+
+// MarshalJSON encodes the server with its "type" as expected by the CLI.
+func (s McpStdioServer) MarshalJSON() ([]byte, error) {
+	type fields McpStdioServer
+	return marshalMcpServer(s.ServerType(), fields(s))
+}
+
+// MarshalJSON encodes the server with its "type" as expected by the CLI.
+func (s McpSSEServer) MarshalJSON() ([]byte, error) {
+	type fields McpSSEServer
+	return marshalMcpServer(s.ServerType(), fields(s))
+}
+
+// MarshalJSON encodes the server with its "type" as expected by the CLI.
+func (s McpHTTPServer) MarshalJSON() ([]byte, error) {
+	type fields McpHTTPServer
+	return marshalMcpServer(s.ServerType(), fields(s))
+}
+
+// MarshalJSON encodes the server with its "type" as expected by the CLI.
+func (s McpSDKServer) MarshalJSON() ([]byte, error) {
+	type fields McpSDKServer
+	return marshalMcpServer(s.ServerType(), fields(s))
+}
+
+// marshalMcpServer encodes the fields of a server, which must be a struct without a MarshalJSON
+// method, as a JSON object whose first member is the server's type.
+func marshalMcpServer(serverType McpServerType, fields any) ([]byte, error) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	typeJSON, _ := json.Marshal(serverType)
+	encoded := append([]byte(`{"type":`), typeJSON...)
+	if len(data) > len("{}") {
+		encoded = append(encoded, ',')
+	}
+	return append(encoded, data[1:]...), nil
+}
+
+// ParseMcpServerConfig decodes the JSON configuration of a single MCP server, as found under
+// "mcpServers" in .mcp.json files, into the McpServerConfig variant named by its "type".
+// A missing type means stdio. An unknown type returns an *errors.McpConfigError.
+func ParseMcpServerConfig(data []byte) (McpServerConfig, error) {
+	var header struct {
+		Type McpServerType `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, errors.NewMcpConfigError("invalid MCP server configuration", "", err)
+	}
+
+	var server McpServerConfig
+	var err error
+	switch header.Type {
+	case "", McpServerTypeStdio:
+		var stdio McpStdioServer
+		err = json.Unmarshal(data, &stdio)
+		server = stdio
+	case McpServerTypeSSE:
+		var sse McpSSEServer
+		err = json.Unmarshal(data, &sse)
+		server = sse
+	case McpServerTypeHTTP:
+		var http McpHTTPServer
+		err = json.Unmarshal(data, &http)
+		server = http
+	case McpServerTypeSDK:
+		var sdk McpSDKServer
+		err = json.Unmarshal(data, &sdk)
+		server = sdk
+	default:
+		return nil, errors.NewMcpConfigError(fmt.Sprintf(
+			"unknown MCP server type %q (want %q, %q, %q, or %q)", header.Type,
+			McpServerTypeStdio, McpServerTypeSSE, McpServerTypeHTTP, McpServerTypeSDK), "", nil)
+	}
+	if err != nil {
+		return nil, errors.NewMcpConfigError("invalid MCP server configuration", "", err)
+	}
+	return server, nil
+}
+
+// UnmarshalJSON decodes QueryOptions, decoding each of its McpServers into the McpServerConfig
+// variant named by its "type" like ParseMcpServerConfig.
+func (o *QueryOptions) UnmarshalJSON(data []byte) error {
+	type fields QueryOptions
+	decoded := struct {
+		*fields
+		McpServers map[string]json.RawMessage `json:"mcp_servers,omitempty"`
+	}{fields: (*fields)(o)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if decoded.McpServers == nil {
+		return nil
+	}
+
+	o.McpServers = make(map[string]McpServerConfig, len(decoded.McpServers))
+	for name, raw := range decoded.McpServers {
+		server, err := ParseMcpServerConfig(raw)
+		if err != nil {
+			return errors.NewMcpConfigError(fmt.Sprintf("server %q", name), "", err)
+		}
+		o.McpServers[name] = server
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+)
+
+// ProjectMcpConfigFile is the name of the file that holds the shared MCP servers of a project.
+const ProjectMcpConfigFile = ".mcp.json"
+
+// envReference matches ${VAR} and ${VAR:-default} in MCP configuration values.
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// LoadMcpConfig reads the MCP servers from a configuration file in the format of
+// .mcp.json and claude_desktop_config.json, whose servers are listed under "mcpServers".
+// References to environment variables are expanded as described in ParseMcpConfig.
+func LoadMcpConfig(path string) (map[string]McpServerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.NewMcpConfigError("failed to read MCP configuration", path, err)
+	}
+	return parseMcpConfig(data, path, os.LookupEnv)
+}
+
+// LoadProjectMcpConfig reads the MCP servers shared by the project in dir from its .mcp.json file.
+func LoadProjectMcpConfig(dir string) (map[string]McpServerConfig, error) {
+	return LoadMcpConfig(filepath.Join(dir, ProjectMcpConfigFile))
+}
+
+// ClaudeDesktopConfigPath returns the location of the Claude Desktop configuration file of the
+// current user, such as ~/Library/Application Support/Claude/claude_desktop_config.json on macOS.
+func ClaudeDesktopConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", errors.NewMcpConfigError("failed to locate the Claude Desktop configuration", "", err)
+	}
+	return filepath.Join(dir, "Claude", "claude_desktop_config.json"), nil
+}
+
+// ParseMcpConfig parses the MCP servers listed under "mcpServers" in a configuration file.
+// References to environment variables in commands, arguments, environment variables, URLs,
+// and headers are expanded: ${VAR} is replaced by the value of VAR, and ${VAR:-default} by
+// default if VAR is unset or empty. lookupEnv looks up variables; if nil, os.LookupEnv is used.
+// A reference to an unset variable without a default is an error.
+func ParseMcpConfig(data []byte, lookupEnv func(string) (string, bool)) (map[string]McpServerConfig, error) {
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	return parseMcpConfig(data, "", lookupEnv)
+}
+
+// parseMcpConfig implements ParseMcpConfig, reporting errors for the file at path.
+func parseMcpConfig(data []byte, path string, lookupEnv func(string) (string, bool)) (map[string]McpServerConfig, error) {
+	var config struct {
+		McpServers map[string]json.RawMessage `json:"mcpServers"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, errors.NewMcpConfigError("invalid MCP configuration", path, err)
+	}
+
+	var missing []string
+	expand := func(value string) string {
+		return envReference.ReplaceAllStringFunc(value, func(reference string) string {
+			match := envReference.FindStringSubmatch(reference)
+			hasDefault := strings.Contains(reference, ":-")
+			if value, ok := lookupEnv(match[1]); ok && (value != "" || !hasDefault) {
+				return value
+			}
+			if hasDefault {
+				return match[2]
+			}
+			if !slices.Contains(missing, match[1]) {
+				missing = append(missing, match[1])
+			}
+			return ""
+		})
+	}
+	expandMap := func(values map[string]string) {
+		for key, value := range values {
+			values[key] = expand(value)
+		}
+	}
+
+	servers := make(map[string]McpServerConfig, len(config.McpServers))
+	for name, raw := range config.McpServers {
+		server, err := ParseMcpServerConfig(raw)
+		if err != nil {
+			return nil, errors.NewMcpConfigError(fmt.Sprintf("server %q", name), path, err)
+		}
+		switch s := server.(type) {
+		case McpStdioServer:
+			s.Command = expand(s.Command)
+			for i, arg := range s.Args {
+				s.Args[i] = expand(arg)
+			}
+			expandMap(s.Env)
+			server = s
+		case McpSSEServer:
+			s.URL = expand(s.URL)
+			expandMap(s.Headers)
+			server = s
+		case McpHTTPServer:
+			s.URL = expand(s.URL)
+			expandMap(s.Headers)
+			server = s
+		}
+		servers[name] = server
+	}
+
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return nil, errors.NewMcpConfigError(
+			fmt.Sprintf("environment variables not set: %s", strings.Join(missing, ", ")), path, nil)
+	}
+	return servers, nil
+}
@@ -0,0 +1,44 @@
+package types
+
+import "time"
+
+// Reasons a query failed, as reported to Metrics.QueryFinished.
+const (
+	// FailureStart means the CLI could not be started.
+	FailureStart = "start"
+	// FailureProcess means the CLI exited with an error.
+	FailureProcess = "process"
+	// FailureResult means the CLI reported an error result, such as error_max_turns.
+	FailureResult = "result"
+	// FailureBudget means the query was stopped for exceeding its budget.
+	FailureBudget = "budget"
+	// FailureCanceled means the query's context ended before the query did.
+	FailureCanceled = "canceled"
+	// FailureOther covers any other error that ended the query.
+	FailureOther = "other"
+)
+
+// Metrics receives measurements of a client's queries for monitoring. Queries are labeled
+// with QueryOptions.Model, which is empty for the CLI's default model.
+// Its methods are called synchronously, so they must be safe for concurrent use and must not block.
+type Metrics interface {
+	// QueryStarted is called when a query starts.
+	QueryStarted(model string)
+	// QueryFinished is called when a query ends, with its duration and the reason it failed,
+	// one of the Failure constants, or "" if it succeeded.
+	QueryFinished(model string, duration time.Duration, failure string)
+	// ProcessStarted is called when a CLI process has started, with the time it took to
+	// locate, check, and launch the CLI.
+	ProcessStarted(latency time.Duration)
+	// FirstMessage is called with the time from the start of a query to its first message.
+	FirstMessage(model string, latency time.Duration)
+	// ToolCall is called when a tool call returns its result.
+	ToolCall(tool string, isError bool)
+	// Usage is called when a query ends, once per model that responded, with the tokens it used
+	// and their cost in USD. The cost reported by the CLI is split between the models in
+	// proportion to their estimated costs; queries stopped before the CLI reported a cost are
+	// charged the estimate. Subagents may respond with a different model than the query's.
+	Usage(model string, usage Usage, costUSD float64)
+	// StderrTruncated is called when the CLI wrote more error output than is kept.
+	StderrTruncated()
+}
@@ -0,0 +1,20 @@
+package types
+
+import "context"
+
+// Observer is notified about every query of a client, for instrumentation such as tracing.
+// Its methods are called synchronously while messages are delivered, so they must not block.
+type Observer interface {
+	// StartQuery is called when a query starts, before the CLI is launched, with the query's
+	// context and merged options. The returned QueryObserver follows the query; it may be nil.
+	StartQuery(ctx context.Context, options *QueryOptions) QueryObserver
+}
+
+// QueryObserver follows a single query. Its methods are called from one goroutine at a time.
+type QueryObserver interface {
+	// Message is called with every message of the query, in the order they are delivered.
+	Message(message Message)
+	// End is called once after the last message. err is the error that ended the query
+	// early, such as the error of an ErrorMessage or of the query's context, or nil.
+	End(err error)
+}
@@ -0,0 +1,399 @@
+package types
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+// ClientOption configures a Client created with NewClient.
+// *ClientOptions implements ClientOption, so a ClientOptions struct can be passed
+// on its own or combined with functional options such as WithModel.
+type ClientOption interface {
+	// ApplyClientOption applies this option to o.
+	ApplyClientOption(o *ClientOptions)
+}
+
+// ApplyClientOption copies the non-zero fields of o into target and merges
+// o.QueryDefaults into target.QueryDefaults. A nil o leaves target unchanged.
+func (o *ClientOptions) ApplyClientOption(target *ClientOptions) {
+	if o == nil {
+		return
+	}
+	if o.CLIPath != "" {
+		target.CLIPath = o.CLIPath
+	}
+	if o.NodePath != "" {
+		target.NodePath = o.NodePath
+	}
+	if o.CWD != "" {
+		target.CWD = o.CWD
+	}
+	if o.PromptDelivery != "" {
+		target.PromptDelivery = o.PromptDelivery
+	}
+	if o.McpConfigDelivery != "" {
+		target.McpConfigDelivery = o.McpConfigDelivery
+	}
+	if o.SkipVersionCheck {
+		target.SkipVersionCheck = true
+	}
+	if o.RateLimiter != nil {
+		target.RateLimiter = o.RateLimiter
+	}
+	if o.Observer != nil {
+		target.Observer = o.Observer
+	}
+	if o.Metrics != nil {
+		target.Metrics = o.Metrics
+	}
+	if o.Logger != nil {
+		target.Logger = o.Logger
+	}
+	if o.Redactor != nil {
+		target.Redactor = o.Redactor
+	}
+	if o.QueryDefaults != nil {
+		target.QueryDefaults = target.QueryDefaults.Merge(o.QueryDefaults)
+	}
+}
+
+// ClientOptionFunc adapts a function to the ClientOption interface.
+type ClientOptionFunc func(o *ClientOptions)
+
+// ApplyClientOption calls f(o).
+func (f ClientOptionFunc) ApplyClientOption(o *ClientOptions) {
+	f(o)
+}
+
+// QueryOption sets a query option. Passed to NewClient, it becomes a default for every
+// query of that client; passed to NewQueryOptions, it builds options for a single query.
+type QueryOption func(o *QueryOptions)
+
+// ApplyClientOption applies f to the client's default query options.
+func (f QueryOption) ApplyClientOption(o *ClientOptions) {
+	if o.QueryDefaults == nil {
+		o.QueryDefaults = &QueryOptions{}
+	}
+	f(o.QueryDefaults)
+}
+
+// NewQueryOptions builds QueryOptions from functional options.
+func NewQueryOptions(opts ...QueryOption) *QueryOptions {
+	options := &QueryOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// WithCLIPath sets the path to the Claude Code CLI executable.
+func WithCLIPath(path string) ClientOption {
+	return ClientOptionFunc(func(o *ClientOptions) { o.CLIPath = path })
+}
+
+// WithNodePath sets the Node.js binary used to run a JavaScript CLI entry point.
+func WithNodePath(path string) ClientOption {
+	return ClientOptionFunc(func(o *ClientOptions) { o.NodePath = path })
+}
+
+// WithCWD sets the working directory for all queries of the client.
+func WithCWD(dir string) ClientOption {
+	return ClientOptionFunc(func(o *ClientOptions) { o.CWD = dir })
+}
+
+// WithPromptDelivery controls whether prompts are passed via argv or stdin.
+func WithPromptDelivery(delivery PromptDelivery) ClientOption {
+	return ClientOptionFunc(func(o *ClientOptions) { o.PromptDelivery = delivery })
+}
+
+// WithMcpConfigDelivery controls whether MCP server configurations are passed via argv or a temporary file.
+func WithMcpConfigDelivery(delivery McpConfigDelivery) ClientOption {
+	return ClientOptionFunc(func(o *ClientOptions) { o.McpConfigDelivery = delivery })
+}
+
+// WithSkipVersionCheck disables CLI version detection on connect.
+func WithSkipVersionCheck() ClientOption {
+	return ClientOptionFunc(func(o *ClientOptions) { o.SkipVersionCheck = true })
+}
+
+// WithRateLimiter delays the start of queries to keep each model within its rate limit.
+func WithRateLimiter(limiter *RateLimiter) ClientOption {
+	return ClientOptionFunc(func(o *ClientOptions) { o.RateLimiter = limiter })
+}
+
+// WithObserver notifies observer about every query of the client.
+func WithObserver(observer Observer) ClientOption {
+	return ClientOptionFunc(func(o *ClientOptions) { o.Observer = observer })
+}
+
+// WithMetrics reports measurements of the client's queries and CLI processes to metrics.
+func WithMetrics(metrics Metrics) ClientOption {
+	return ClientOptionFunc(func(o *ClientOptions) { o.Metrics = metrics })
+}
+
+// WithLogger logs CLI process lifecycle events, CLI error output, and SDK decisions to logger.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return ClientOptionFunc(func(o *ClientOptions) { o.Logger = logger })
+}
+
+// WithRedactor scrubs secrets matched by redactor from log records and process errors.
+func WithRedactor(redactor *Redactor) ClientOption {
+	return ClientOptionFunc(func(o *ClientOptions) { o.Redactor = redactor })
+}
+
+// WithModel sets the model to use.
+func WithModel(model string) QueryOption {
+	return func(o *QueryOptions) { o.Model = model }
+}
+
+// WithFallbackModel sets the model used when the primary model is overloaded.
+func WithFallbackModel(model string) QueryOption {
+	return func(o *QueryOptions) { o.FallbackModel = model }
+}
+
+// WithTools adds tools to the list of allowed tools.
+func WithTools(tools ...string) QueryOption {
+	return func(o *QueryOptions) { o.AllowedTools = appendUnique(o.AllowedTools, tools...) }
+}
+
+// WithDisallowedTools adds tools to the list of prohibited tools.
+func WithDisallowedTools(tools ...string) QueryOption {
+	return func(o *QueryOptions) { o.DisallowedTools = appendUnique(o.DisallowedTools, tools...) }
+}
+
+// WithMCPServer adds or replaces the MCP server with the given name.
+func WithMCPServer(name string, config McpServerConfig) QueryOption {
+	return func(o *QueryOptions) {
+		if o.McpServers == nil {
+			o.McpServers = make(map[string]McpServerConfig)
+		}
+		o.McpServers[name] = config
+	}
+}
+
+// WithMCPServers adds or replaces MCP servers, such as those loaded with LoadMcpConfig.
+func WithMCPServers(servers map[string]McpServerConfig) QueryOption {
+	return func(o *QueryOptions) {
+		if o.McpServers == nil {
+			o.McpServers = make(map[string]McpServerConfig, len(servers))
+		}
+		maps.Copy(o.McpServers, servers)
+	}
+}
+
+// WithMCPTools adds MCP tools (e.g., "mcp__github__create_issue") to the list of allowed tools.
+func WithMCPTools(tools ...string) QueryOption {
+	return func(o *QueryOptions) { o.McpTools = appendUnique(o.McpTools, tools...) }
+}
+
+// WithPermissionMode sets how tool permissions are handled.
+func WithPermissionMode(mode PermissionMode) QueryOption {
+	return func(o *QueryOptions) { o.PermissionMode = mode }
+}
+
+// WithSystemPrompt replaces the default system prompt.
+func WithSystemPrompt(prompt string) QueryOption {
+	return func(o *QueryOptions) { o.SystemPrompt = prompt }
+}
+
+// WithAppendSystemPrompt adds instructions to the default system prompt.
+func WithAppendSystemPrompt(prompt string) QueryOption {
+	return func(o *QueryOptions) { o.AppendSystemPrompt = prompt }
+}
+
+// WithMaxBudgetUSD stops the query once its estimated cost exceeds usd.
+func WithMaxBudgetUSD(usd float64) QueryOption {
+	return func(o *QueryOptions) { o.MaxBudgetUSD = usd }
+}
+
+// WithMaxTokens stops the query once its total token usage exceeds tokens.
+func WithMaxTokens(tokens int) QueryOption {
+	return func(o *QueryOptions) { o.MaxTokens = tokens }
+}
+
+// WithBudget charges the query to a budget shared with other queries.
+func WithBudget(budget *Budget) QueryOption {
+	return func(o *QueryOptions) { o.Budget = budget }
+}
+
+// WithRetry retries the query according to policy when it fails for a transient reason.
+func WithRetry(policy *RetryPolicy) QueryOption {
+	return func(o *QueryOptions) { o.Retry = policy }
+}
+
+// WithMaxTurns limits the number of conversation turns.
+func WithMaxTurns(turns int) QueryOption {
+	return func(o *QueryOptions) { o.MaxTurns = turns }
+}
+
+// WithMaxThinkingTokens limits the tokens Claude can use for internal reasoning.
+func WithMaxThinkingTokens(tokens int) QueryOption {
+	return func(o *QueryOptions) { o.MaxThinkingTokens = tokens }
+}
+
+// WithAddDirs adds directories that Claude is allowed to access besides the working directory.
+func WithAddDirs(dirs ...string) QueryOption {
+	return func(o *QueryOptions) { o.AddDirs = appendUnique(o.AddDirs, dirs...) }
+}
+
+// WithSettings sets a settings file path or inline JSON settings string.
+func WithSettings(settings string) QueryOption {
+	return func(o *QueryOptions) { o.Settings = settings }
+}
+
+// WithSettingSources selects which settings files the CLI loads.
+func WithSettingSources(sources ...SettingSource) QueryOption {
+	return func(o *QueryOptions) { o.SettingSources = append([]SettingSource{}, sources...) }
+}
+
+// WithAgent adds or replaces the custom subagent with the given name.
+func WithAgent(name string, agent AgentDefinition) QueryOption {
+	return func(o *QueryOptions) {
+		if o.Agents == nil {
+			o.Agents = make(map[string]AgentDefinition)
+		}
+		o.Agents[name] = agent
+	}
+}
+
+// WithResume resumes the conversation with the given session ID.
+func WithResume(sessionID string) QueryOption {
+	return func(o *QueryOptions) { o.Resume = sessionID }
+}
+
+// WithContinueConversation continues the most recent conversation.
+func WithContinueConversation() QueryOption {
+	return func(o *QueryOptions) { o.ContinueConversation = true }
+}
+
+// WithIncludePartialMessages streams StreamEvent messages while responses are generated.
+func WithIncludePartialMessages() QueryOption {
+	return func(o *QueryOptions) { o.IncludePartialMessages = true }
+}
+
+// Merge returns a new QueryOptions that combines o with override, leaving both unchanged.
+// Scalar fields set in override replace those in o, list fields are appended without
+// duplicates, and map fields are merged with entries from override taking precedence.
+// Boolean flags are enabled if they are enabled in either.
+//
+// Settings in override also replace conflicting ones in o: Resume replaces
+// ContinueConversation and the other way around, and a tool in override's AllowedTools or
+// DisallowedTools is removed from o's DisallowedTools or AllowedTools respectively.
+// Either side may be nil.
+func (o *QueryOptions) Merge(override *QueryOptions) *QueryOptions {
+	merged := &QueryOptions{}
+	if o != nil {
+		*merged = *o
+		merged.AllowedTools = slices.Clone(o.AllowedTools)
+		merged.DisallowedTools = slices.Clone(o.DisallowedTools)
+		merged.McpTools = slices.Clone(o.McpTools)
+		merged.AddDirs = slices.Clone(o.AddDirs)
+		merged.SettingSources = slices.Clone(o.SettingSources)
+		merged.McpServers = maps.Clone(o.McpServers)
+		merged.Agents = maps.Clone(o.Agents)
+	}
+	if override == nil {
+		return merged
+	}
+
+	merged.AllowedTools = slices.DeleteFunc(merged.AllowedTools, func(tool string) bool {
+		return slices.Contains(override.DisallowedTools, tool)
+	})
+	merged.DisallowedTools = slices.DeleteFunc(merged.DisallowedTools, func(tool string) bool {
+		return slices.Contains(override.AllowedTools, tool)
+	})
+	merged.AllowedTools = appendUnique(merged.AllowedTools, override.AllowedTools...)
+	merged.DisallowedTools = appendUnique(merged.DisallowedTools, override.DisallowedTools...)
+	merged.McpTools = appendUnique(merged.McpTools, override.McpTools...)
+	merged.AddDirs = appendUnique(merged.AddDirs, override.AddDirs...)
+	if override.SettingSources != nil {
+		merged.SettingSources = slices.Clone(override.SettingSources)
+	}
+
+	merged.McpServers = mergeMaps(merged.McpServers, override.McpServers)
+	merged.Agents = mergeMaps(merged.Agents, override.Agents)
+
+	if override.MaxThinkingTokens != 0 {
+		merged.MaxThinkingTokens = override.MaxThinkingTokens
+	}
+	if override.SystemPrompt != "" {
+		merged.SystemPrompt = override.SystemPrompt
+	}
+	if override.AppendSystemPrompt != "" {
+		merged.AppendSystemPrompt = override.AppendSystemPrompt
+	}
+	if override.PermissionMode != "" {
+		merged.PermissionMode = override.PermissionMode
+	}
+	if override.Resume != "" {
+		merged.Resume = override.Resume
+		if !override.ContinueConversation {
+			merged.ContinueConversation = false
+		}
+	}
+	if override.MaxTurns != 0 {
+		merged.MaxTurns = override.MaxTurns
+	}
+	if override.Model != "" {
+		merged.Model = override.Model
+	}
+	if override.PermissionPromptToolName != "" {
+		merged.PermissionPromptToolName = override.PermissionPromptToolName
+	}
+	if override.CWD != "" {
+		merged.CWD = override.CWD
+	}
+	if override.Settings != "" {
+		merged.Settings = override.Settings
+	}
+	if override.FallbackModel != "" {
+		merged.FallbackModel = override.FallbackModel
+	}
+	if override.User != "" {
+		merged.User = override.User
+	}
+	if override.MaxBudgetUSD != 0 {
+		merged.MaxBudgetUSD = override.MaxBudgetUSD
+	}
+	if override.MaxTokens != 0 {
+		merged.MaxTokens = override.MaxTokens
+	}
+	if override.Budget != nil {
+		merged.Budget = override.Budget
+	}
+	if override.Retry != nil {
+		merged.Retry = override.Retry
+	}
+
+	if override.ContinueConversation && override.Resume == "" {
+		merged.Resume = ""
+	}
+	merged.ContinueConversation = merged.ContinueConversation || override.ContinueConversation
+	merged.IncludePartialMessages = merged.IncludePartialMessages || override.IncludePartialMessages
+	merged.StrictMcpConfig = merged.StrictMcpConfig || override.StrictMcpConfig
+
+	return merged
+}
+
+// appendUnique appends the values not already present in list.
+func appendUnique[T comparable](list []T, values ...T) []T {
+	for _, value := range values {
+		if !slices.Contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// mergeMaps returns base with the entries of override added, allocating base if needed.
+func mergeMaps[V any](base map[string]V, override map[string]V) map[string]V {
+	if len(override) == 0 {
+		return base
+	}
+	if base == nil {
+		base = make(map[string]V, len(override))
+	}
+	maps.Copy(base, override)
+	return base
+}
@@ -0,0 +1,222 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+)
+
+// Supported media types for image and document content.
+const (
+	MediaTypeJPEG = "image/jpeg"
+	MediaTypePNG  = "image/png"
+	MediaTypeGIF  = "image/gif"
+	MediaTypeWebP = "image/webp"
+	MediaTypePDF  = "application/pdf"
+	MediaTypeText = "text/plain"
+)
+
+// ContentSource describes the payload of an image or document content block.
+type ContentSource struct {
+	// Type is the encoding of Data: "base64" for binary payloads or "text" for plain text documents.
+	Type string `json:"type"`
+	// MediaType is the MIME type of the payload (e.g., "image/png", "application/pdf").
+	MediaType string `json:"media_type"`
+	// Data contains the encoded payload.
+	Data string `json:"data"`
+}
+
+// ImageBlock represents an image supplied as part of a user prompt.
+type ImageBlock struct {
+	// Source contains the base64-encoded image and its media type.
+	Source ContentSource `json:"source"`
+}
+
+func (b *ImageBlock) BlockType() ContentBlockType {
+	return ContentBlockTypeImage
+}
+
+// DocumentBlock represents a PDF or plain text document supplied as part of a user prompt.
+type DocumentBlock struct {
+	// Source contains the document payload and its media type.
+	Source ContentSource `json:"source"`
+	// Title is an optional title shown to the model alongside the document.
+	Title string `json:"title,omitempty"`
+}
+
+func (b *DocumentBlock) BlockType() ContentBlockType {
+	return ContentBlockTypeDocument
+}
+
+// Prompt composes a user message from text, image, and document content blocks.
+// Builder methods record the first error they encounter, which is reported by Err
+// and by any query the prompt is passed to.
+type Prompt struct {
+	blocks []ContentBlock
+	err    error
+}
+
+// NewPrompt creates an empty Prompt to which content blocks can be appended.
+func NewPrompt() *Prompt {
+	return &Prompt{}
+}
+
+// Text appends a text block to the prompt.
+func (p *Prompt) Text(text string) *Prompt {
+	p.blocks = append(p.blocks, NewTextBlock(text))
+	return p
+}
+
+// ImageFile appends the image at path, inferring its media type from the file extension.
+func (p *Prompt) ImageFile(path string) *Prompt {
+	mediaType, ok := imageMediaTypes[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return p.fail(fmt.Sprintf("unsupported image file extension: %s", path), nil)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return p.fail(fmt.Sprintf("failed to read image file: %s", path), err)
+	}
+	return p.ImageBytes(data, mediaType)
+}
+
+// ImageBytes appends raw image data with the given media type.
+func (p *Prompt) ImageBytes(data []byte, mediaType string) *Prompt {
+	return p.ImageBase64(base64.StdEncoding.EncodeToString(data), mediaType)
+}
+
+// ImageBase64 appends base64-encoded image data with the given media type.
+func (p *Prompt) ImageBase64(data string, mediaType string) *Prompt {
+	if !isImageMediaType(mediaType) {
+		return p.fail(fmt.Sprintf("unsupported image media type: %s", mediaType), nil)
+	}
+	p.blocks = append(p.blocks, &ImageBlock{
+		Source: ContentSource{Type: "base64", MediaType: mediaType, Data: data},
+	})
+	return p
+}
+
+// DocumentFile appends the PDF or plain text document at path, inferring its media type from the file extension.
+func (p *Prompt) DocumentFile(path string) *Prompt {
+	mediaType, ok := documentMediaTypes[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return p.fail(fmt.Sprintf("unsupported document file extension: %s", path), nil)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return p.fail(fmt.Sprintf("failed to read document file: %s", path), err)
+	}
+	return p.DocumentBytes(data, mediaType)
+}
+
+// DocumentBytes appends raw document data with the given media type ("application/pdf" or "text/plain").
+func (p *Prompt) DocumentBytes(data []byte, mediaType string) *Prompt {
+	if mediaType == MediaTypeText {
+		p.blocks = append(p.blocks, &DocumentBlock{
+			Source: ContentSource{Type: "text", MediaType: mediaType, Data: string(data)},
+		})
+		return p
+	}
+	return p.DocumentBase64(base64.StdEncoding.EncodeToString(data), mediaType)
+}
+
+// DocumentBase64 appends a base64-encoded PDF document.
+func (p *Prompt) DocumentBase64(data string, mediaType string) *Prompt {
+	if mediaType != MediaTypePDF {
+		return p.fail(fmt.Sprintf("unsupported base64 document media type: %s", mediaType), nil)
+	}
+	p.blocks = append(p.blocks, &DocumentBlock{
+		Source: ContentSource{Type: "base64", MediaType: mediaType, Data: data},
+	})
+	return p
+}
+
+// Blocks returns the content blocks added to the prompt so far.
+func (p *Prompt) Blocks() []ContentBlock {
+	return p.blocks
+}
+
+// Err returns the first error encountered while building the prompt, if any.
+// A nil prompt reports an error.
+func (p *Prompt) Err() error {
+	if p == nil {
+		return errors.NewClaudeSDKError("prompt is nil", nil)
+	}
+	if p.err == nil && len(p.blocks) == 0 {
+		return errors.NewClaudeSDKError("prompt is empty", nil)
+	}
+	return p.err
+}
+
+// PlainText returns the prompt as a single string if it consists only of text blocks.
+// Prompts containing images or documents report false.
+func (p *Prompt) PlainText() (string, bool) {
+	texts := make([]string, 0, len(p.blocks))
+	for _, block := range p.blocks {
+		textBlock, ok := block.(*TextBlock)
+		if !ok {
+			return "", false
+		}
+		texts = append(texts, textBlock.Text)
+	}
+	return strings.Join(texts, "\n\n"), true
+}
+
+// MarshalJSON encodes the prompt as the content array of a user message.
+func (p *Prompt) MarshalJSON() ([]byte, error) {
+	content := make([]map[string]any, 0, len(p.blocks))
+	for _, block := range p.blocks {
+		entry := map[string]any{"type": block.BlockType()}
+		switch b := block.(type) {
+		case *TextBlock:
+			entry["text"] = b.Text
+		case *ImageBlock:
+			entry["source"] = b.Source
+		case *DocumentBlock:
+			entry["source"] = b.Source
+			if b.Title != "" {
+				entry["title"] = b.Title
+			}
+		default:
+			return nil, fmt.Errorf("unsupported prompt content block: %s", block.BlockType())
+		}
+		content = append(content, entry)
+	}
+	return json.Marshal(content)
+}
+
+func (p *Prompt) fail(message string, cause error) *Prompt {
+	if p.err == nil {
+		p.err = errors.NewClaudeSDKError(message, cause)
+	}
+	return p
+}
+
+var imageMediaTypes = map[string]string{
+	".jpg":  MediaTypeJPEG,
+	".jpeg": MediaTypeJPEG,
+	".png":  MediaTypePNG,
+	".gif":  MediaTypeGIF,
+	".webp": MediaTypeWebP,
+}
+
+var documentMediaTypes = map[string]string{
+	".pdf": MediaTypePDF,
+	".txt": MediaTypeText,
+	".log": MediaTypeText,
+	".md":  MediaTypeText,
+}
+
+func isImageMediaType(mediaType string) bool {
+	for _, supported := range imageMediaTypes {
+		if mediaType == supported {
+			return true
+		}
+	}
+	return false
+}
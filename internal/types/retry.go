@@ -0,0 +1,138 @@
+package types
+
+import (
+	stderrors "errors"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/apierror"
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+)
+
+// Default retry policy settings
+const (
+	DefaultRetryMaxAttempts    = 3
+	DefaultRetryInitialBackoff = time.Second
+	DefaultRetryMaxBackoff     = 30 * time.Second
+	DefaultRetryMultiplier     = 2.0
+	DefaultRetryResumePrompt   = "The previous attempt was interrupted by an error. Continue where you left off."
+)
+
+// RetryPolicy retries queries that fail for transient reasons, such as API overload,
+// rate limiting, or network errors. Zero values are replaced by the defaults.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the delay grows by after each retry.
+	Multiplier float64
+	// ResumePrompt is sent when a retry resumes the session of the failed attempt.
+	ResumePrompt string
+	// Retryable overrides the classification of failures. If nil, IsRetryable is used.
+	Retryable func(failure *QueryFailure) bool
+}
+
+// QueryFailure describes how an attempt of a query failed.
+type QueryFailure struct {
+	// Attempt is the number of the failed attempt, starting at 1.
+	Attempt int
+	// Result is the failed ResultMessage of the attempt, or nil if the CLI did not report one.
+	Result *ResultMessage
+	// Err is the error that ended the attempt, such as a *errors.ProcessError, or nil.
+	Err error
+}
+
+// transientAPIErrors are the error types of the API that usually clear up on their own.
+var transientAPIErrors = []string{"overloaded_error", "rate_limit_error", "api_error", "timeout_error"}
+
+// transientStatuses are the HTTP statuses of API responses that usually clear up on their own.
+var transientStatuses = []string{"408", "429", "500", "502", "503", "504", "529"}
+
+// transientMessages match, at the start of a line, the messages the CLI and Node.js report for
+// network failures and API errors without a status.
+var transientMessages = regexp.MustCompile(`(?im)^(?:` +
+	`(?:error:\s*)?api error:?\s*\(?(?:request timed out|connection error)` +
+	`|(?:\w*error:\s*)?(?:(?:read|connect|getaddrinfo|write)\s+)?(?:econnreset|econnrefused|etimedout|eai_again|enotfound)\b` +
+	`|(?:\w*error:\s*)?(?:socket hang up|fetch failed)` +
+	`)`)
+
+// IsRetryable is the default classification of failures. A failure is retryable if the CLI
+// reported an error during execution, or if its result text or the CLI's error output reports
+// a transient condition: an API error whose type, such as overloaded_error or rate_limit_error,
+// or HTTP status, such as 429 or 529, is transient, a request timeout, or a network failure.
+// Results that hit a configured limit, such as error_max_turns, are never retryable.
+func IsRetryable(failure *QueryFailure) bool {
+	if result := failure.Result; result != nil {
+		switch result.Subtype {
+		case "error_during_execution":
+			return true
+		case "success":
+			return result.Result != nil && isTransient(*result.Result)
+		default:
+			return false
+		}
+	}
+
+	var processErr *errors.ProcessError
+	if stderrors.As(failure.Err, &processErr) {
+		return isTransient(processErr.Stderr)
+	}
+	return false
+}
+
+// isTransient reports whether CLI output reports a transient failure. The type of a structured
+// API error takes precedence over its HTTP status.
+func isTransient(output string) bool {
+	for _, apiErr := range apierror.Find(output) {
+		if apiErr.Type != "" {
+			if slices.Contains(transientAPIErrors, apiErr.Type) {
+				return true
+			}
+			continue
+		}
+		if slices.Contains(transientStatuses, apiErr.Status) {
+			return true
+		}
+	}
+	return transientMessages.MatchString(output)
+}
+
+// WithDefaults returns a copy of the policy with zero values replaced by the defaults.
+func (p RetryPolicy) WithDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultRetryMultiplier
+	}
+	if p.ResumePrompt == "" {
+		p.ResumePrompt = DefaultRetryResumePrompt
+	}
+	if p.Retryable == nil {
+		p.Retryable = IsRetryable
+	}
+	return p
+}
+
+// Backoff returns the delay before retrying the given failed attempt, starting at 1.
+// The delay grows exponentially and is jittered to between half and all of its value,
+// so that concurrent queries failing together do not retry in lockstep.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < attempt && delay < float64(p.MaxBackoff); i++ {
+		delay *= p.Multiplier
+	}
+	delay = min(delay, float64(p.MaxBackoff))
+	return time.Duration(delay/2 + rand.Float64()*delay/2)
+}
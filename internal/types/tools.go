@@ -0,0 +1,247 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+)
+
+// Names of the built-in Claude Code tools, for use in AllowedTools, DisallowedTools,
+// and when inspecting ToolUseBlock.Name.
+const (
+	ToolBash         = "Bash"
+	ToolBashOutput   = "BashOutput"
+	ToolKillShell    = "KillShell"
+	ToolRead         = "Read"
+	ToolWrite        = "Write"
+	ToolEdit         = "Edit"
+	ToolMultiEdit    = "MultiEdit"
+	ToolGlob         = "Glob"
+	ToolGrep         = "Grep"
+	ToolLS           = "LS"
+	ToolWebFetch     = "WebFetch"
+	ToolWebSearch    = "WebSearch"
+	ToolTask         = "Task"
+	ToolTodoWrite    = "TodoWrite"
+	ToolNotebookEdit = "NotebookEdit"
+	ToolExitPlanMode = "ExitPlanMode"
+)
+
+// ToolRule builds a scoped permission rule such as "Bash(git diff:*)" or "Edit(src/**)".
+// If specifier is empty, the bare tool name is returned, which matches every use of the tool.
+func ToolRule(tool string, specifier string) string {
+	if specifier == "" {
+		return tool
+	}
+	return fmt.Sprintf("%s(%s)", tool, specifier)
+}
+
+// BashCommandRule builds a rule matching exactly the given Bash command, such as "Bash(npm test)".
+func BashCommandRule(command string) string {
+	return ToolRule(ToolBash, command)
+}
+
+// BashPrefixRule builds a rule matching every Bash command starting with prefix,
+// such as "Bash(git diff:*)".
+func BashPrefixRule(prefix string) string {
+	return ToolRule(ToolBash, prefix+":*")
+}
+
+// MCPToolName returns the tool name under which an MCP server's tool is exposed,
+// such as "mcp__github__create_issue". If tool is empty, the name matches every tool of the server.
+func MCPToolName(server string, tool string) string {
+	if tool == "" {
+		return "mcp__" + server
+	}
+	return "mcp__" + server + "__" + tool
+}
+
+// IsMCPTool reports whether name refers to a tool provided by an MCP server.
+func IsMCPTool(name string) bool {
+	return strings.HasPrefix(name, "mcp__")
+}
+
+// BashInput is the input of the Bash tool.
+type BashInput struct {
+	Command         string `json:"command"`
+	Description     string `json:"description,omitempty"`
+	Timeout         int    `json:"timeout,omitempty"`
+	RunInBackground bool   `json:"run_in_background,omitempty"`
+}
+
+// ReadInput is the input of the Read tool.
+type ReadInput struct {
+	FilePath string `json:"file_path"`
+	Offset   int    `json:"offset,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+}
+
+// WriteInput is the input of the Write tool.
+type WriteInput struct {
+	FilePath string `json:"file_path"`
+	Content  string `json:"content"`
+}
+
+// EditInput is the input of the Edit tool.
+type EditInput struct {
+	FilePath   string `json:"file_path"`
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+// EditOperation is a single replacement within a MultiEdit tool call.
+type EditOperation struct {
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+// MultiEditInput is the input of the MultiEdit tool.
+type MultiEditInput struct {
+	FilePath string          `json:"file_path"`
+	Edits    []EditOperation `json:"edits"`
+}
+
+// GlobInput is the input of the Glob tool.
+type GlobInput struct {
+	Pattern string `json:"pattern"`
+	Path    string `json:"path,omitempty"`
+}
+
+// GrepInput is the input of the Grep tool.
+type GrepInput struct {
+	Pattern         string `json:"pattern"`
+	Path            string `json:"path,omitempty"`
+	Glob            string `json:"glob,omitempty"`
+	Type            string `json:"type,omitempty"`
+	OutputMode      string `json:"output_mode,omitempty"`
+	CaseInsensitive bool   `json:"-i,omitempty"`
+	HeadLimit       int    `json:"head_limit,omitempty"`
+	Multiline       bool   `json:"multiline,omitempty"`
+}
+
+// WebFetchInput is the input of the WebFetch tool.
+type WebFetchInput struct {
+	URL    string `json:"url"`
+	Prompt string `json:"prompt"`
+}
+
+// WebSearchInput is the input of the WebSearch tool.
+type WebSearchInput struct {
+	Query          string   `json:"query"`
+	AllowedDomains []string `json:"allowed_domains,omitempty"`
+	BlockedDomains []string `json:"blocked_domains,omitempty"`
+}
+
+// TaskInput is the input of the Task tool, which delegates work to a subagent.
+type TaskInput struct {
+	Description  string `json:"description"`
+	Prompt       string `json:"prompt"`
+	SubagentType string `json:"subagent_type,omitempty"`
+}
+
+// TodoStatus is the status of an item in a TodoWrite list.
+type TodoStatus string
+
+const (
+	// TodoStatusPending marks a todo that has not been started.
+	TodoStatusPending TodoStatus = "pending"
+	// TodoStatusInProgress marks the todo currently being worked on.
+	TodoStatusInProgress TodoStatus = "in_progress"
+	// TodoStatusCompleted marks a finished todo.
+	TodoStatusCompleted TodoStatus = "completed"
+)
+
+// TodoItem is a single entry of a TodoWrite list.
+type TodoItem struct {
+	// Content describes the task in imperative form (e.g., "Run tests").
+	Content string `json:"content"`
+	// Status is the current status of the task.
+	Status TodoStatus `json:"status"`
+	// ActiveForm describes the task in present continuous form (e.g., "Running tests").
+	ActiveForm string `json:"activeForm,omitempty"`
+}
+
+// TodoWriteInput is the input of the TodoWrite tool, which replaces the whole todo list.
+type TodoWriteInput struct {
+	Todos []TodoItem `json:"todos"`
+}
+
+// NotebookEditInput is the input of the NotebookEdit tool.
+type NotebookEditInput struct {
+	NotebookPath string `json:"notebook_path"`
+	CellID       string `json:"cell_id,omitempty"`
+	NewSource    string `json:"new_source"`
+	CellType     string `json:"cell_type,omitempty"`
+	EditMode     string `json:"edit_mode,omitempty"`
+}
+
+// DecodeInput decodes the tool input into v, which should be a pointer to one of the
+// typed tool input structs (such as *BashInput) or any other JSON-compatible value.
+func (b *ToolUseBlock) DecodeInput(v any) error {
+	data, err := json.Marshal(b.Input)
+	if err != nil {
+		return errors.NewMessageParseError(fmt.Sprintf("failed to encode input of tool %s", b.Name), b.Input, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.NewMessageParseError(fmt.Sprintf("failed to decode input of tool %s", b.Name), b.Input, err)
+	}
+	return nil
+}
+
+// TypedInput decodes the input of a built-in tool into its typed input struct,
+// such as *BashInput for the Bash tool. For other tools it returns Input unchanged.
+func (b *ToolUseBlock) TypedInput() (any, error) {
+	var v any
+	switch b.Name {
+	case ToolBash:
+		v = &BashInput{}
+	case ToolRead:
+		v = &ReadInput{}
+	case ToolWrite:
+		v = &WriteInput{}
+	case ToolEdit:
+		v = &EditInput{}
+	case ToolMultiEdit:
+		v = &MultiEditInput{}
+	case ToolGlob:
+		v = &GlobInput{}
+	case ToolGrep:
+		v = &GrepInput{}
+	case ToolWebFetch:
+		v = &WebFetchInput{}
+	case ToolWebSearch:
+		v = &WebSearchInput{}
+	case ToolTask:
+		v = &TaskInput{}
+	case ToolTodoWrite:
+		v = &TodoWriteInput{}
+	case ToolNotebookEdit:
+		v = &NotebookEditInput{}
+	default:
+		return b.Input, nil
+	}
+	if err := b.DecodeInput(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// FilePath returns the file targeted by a file-based tool call (Read, Write, Edit,
+// MultiEdit, or NotebookEdit), or false for any other tool.
+func (b *ToolUseBlock) FilePath() (string, bool) {
+	var key string
+	switch b.Name {
+	case ToolRead, ToolWrite, ToolEdit, ToolMultiEdit:
+		key = "file_path"
+	case ToolNotebookEdit:
+		key = "notebook_path"
+	default:
+		return "", false
+	}
+	path, ok := b.Input[key].(string)
+	return path, ok
+}
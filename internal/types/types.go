@@ -1,5 +1,13 @@
 package types
 
+import (
+	"log/slog"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/budget"
+	"github.com/musaprg/claude-code-sdk-go/internal/ratelimit"
+	"github.com/musaprg/claude-code-sdk-go/internal/redact"
+)
+
 // MessageType represents the type of message in a Claude Code conversation.
 type MessageType string
 
@@ -12,6 +20,10 @@ const (
 	MessageTypeSystem MessageType = "system"
 	// MessageTypeResult represents final result messages with conversation metadata.
 	MessageTypeResult MessageType = "result"
+	// MessageTypeStreamEvent represents partial message updates emitted while a response is generated.
+	MessageTypeStreamEvent MessageType = "stream_event"
+	// MessageTypeError represents an SDK-side failure that ended the message stream early.
+	MessageTypeError MessageType = "error"
 )
 
 // ContentBlockType represents the type of content block within a message.
@@ -24,6 +36,10 @@ const (
 	ContentBlockTypeToolUse ContentBlockType = "tool_use"
 	// ContentBlockTypeToolResult represents the result of a tool execution.
 	ContentBlockTypeToolResult ContentBlockType = "tool_result"
+	// ContentBlockTypeImage represents an image supplied in a user prompt.
+	ContentBlockTypeImage ContentBlockType = "image"
+	// ContentBlockTypeDocument represents a PDF or text document supplied in a user prompt.
+	ContentBlockTypeDocument ContentBlockType = "document"
 )
 
 // PermissionMode defines how tools are permitted to run during a Claude Code session.
@@ -36,6 +52,49 @@ const (
 	PermissionModeAcceptEdits PermissionMode = "acceptEdits"
 	// PermissionModeBypassPermissions bypasses all permission checks (recommended only for sandboxes).
 	PermissionModeBypassPermissions PermissionMode = "bypassPermissions"
+	// PermissionModePlan lets Claude analyze and plan without executing tools that modify anything.
+	PermissionModePlan PermissionMode = "plan"
+)
+
+// SettingSource identifies a Claude Code settings file location that the CLI loads settings from.
+type SettingSource string
+
+const (
+	// SettingSourceUser loads global user settings (~/.claude/settings.json).
+	SettingSourceUser SettingSource = "user"
+	// SettingSourceProject loads shared project settings (.claude/settings.json).
+	SettingSourceProject SettingSource = "project"
+	// SettingSourceLocal loads local project settings (.claude/settings.local.json).
+	SettingSourceLocal SettingSource = "local"
+)
+
+// PromptDelivery controls how prompts are handed to the Claude Code CLI process.
+type PromptDelivery string
+
+const (
+	// PromptDeliveryAuto passes short prompts as command-line arguments and switches to stdin
+	// once a prompt is too large to be safely placed in argv. This is the default.
+	PromptDeliveryAuto PromptDelivery = "auto"
+	// PromptDeliveryArgv always passes the prompt as a command-line argument.
+	PromptDeliveryArgv PromptDelivery = "argv"
+	// PromptDeliveryStdin always streams the prompt over stdin and keeps system prompts out of argv.
+	PromptDeliveryStdin PromptDelivery = "stdin"
+)
+
+// McpConfigDelivery controls how the MCP server configuration of a query is handed to the Claude Code CLI process.
+type McpConfigDelivery string
+
+const (
+	// McpConfigDeliveryAuto writes the configuration to a temporary file when any server has
+	// environment variables or headers, which often carry credentials, and passes it as a
+	// command-line argument otherwise. This is the default.
+	McpConfigDeliveryAuto McpConfigDelivery = "auto"
+	// McpConfigDeliveryArgv always passes the configuration as a command-line argument,
+	// where other users of the machine may see it in the process list.
+	McpConfigDeliveryArgv McpConfigDelivery = "argv"
+	// McpConfigDeliveryFile always writes the configuration to a temporary file readable only
+	// by the current user, which is removed when the query ends.
+	McpConfigDeliveryFile McpConfigDelivery = "file"
 )
 
 // Message represents a message in the Claude Code conversation.
@@ -46,7 +105,7 @@ type Message interface {
 }
 
 // ContentBlock represents a content block within a message.
-// Content blocks can contain text, tool uses, or tool results.
+// Content blocks can contain text, tool uses, tool results, or prompt attachments such as images and documents.
 type ContentBlock interface {
 	// BlockType returns the ContentBlockType of this block.
 	BlockType() ContentBlockType
@@ -55,7 +114,11 @@ type ContentBlock interface {
 // UserMessage represents a message from the human user to Claude.
 type UserMessage struct {
 	// Content contains the user's prompt or question text.
+	// For messages carrying tool results, it contains the text of the first tool result.
 	Content string `json:"content"`
+	// ContentBlocks contains the structured content of the message, such as the
+	// ToolResultBlocks reported back to Claude after tool executions. It is nil for plain text messages.
+	ContentBlocks []ContentBlock `json:"content_blocks,omitempty"`
 }
 
 func (m *UserMessage) Type() MessageType {
@@ -66,11 +129,30 @@ func NewUserMessage(content string) *UserMessage {
 	return &UserMessage{Content: content}
 }
 
+// Usage contains token counts reported by the API for a message.
+type Usage = budget.Usage
+
+// Budget is a spending limit shared by many queries.
+type Budget = budget.Budget
+
+// RateLimiter paces the start of queries per model.
+type RateLimiter = ratelimit.Limiter
+
+// Redactor replaces secrets in text before it is logged or reported in errors.
+type Redactor = redact.Redactor
+
 // AssistantMessage represents a message from Claude's AI assistant.
 type AssistantMessage struct {
 	// Content contains the assistant's response as a sequence of content blocks,
 	// which may include text, tool uses, and tool results.
 	Content []ContentBlock `json:"content"`
+	// ID is the API message ID. The CLI may report one API message as several
+	// AssistantMessages sharing the same ID, one per content block.
+	ID string `json:"id,omitempty"`
+	// Model is the model that generated the message.
+	Model string `json:"model,omitempty"`
+	// Usage contains the token usage of the API message, if reported.
+	Usage *Usage `json:"usage,omitempty"`
 }
 
 func (m *AssistantMessage) Type() MessageType {
@@ -134,6 +216,50 @@ func NewResultMessage(subtype string, durationMs, durationAPIMs, numTurns int, i
 	}
 }
 
+// StreamEvent represents a partial message update emitted while Claude is generating a response.
+// Stream events are only produced when QueryOptions.IncludePartialMessages is enabled.
+type StreamEvent struct {
+	// UUID is the unique identifier of this event.
+	UUID string `json:"uuid"`
+	// SessionID is the identifier of the conversation session the event belongs to.
+	SessionID string `json:"session_id"`
+	// Event contains the raw Anthropic API stream event (e.g., "content_block_delta").
+	Event map[string]any `json:"event"`
+	// ParentToolUseID is the ID of the tool use that spawned this event, if any.
+	ParentToolUseID *string `json:"parent_tool_use_id,omitempty"`
+}
+
+func (m *StreamEvent) Type() MessageType {
+	return MessageTypeStreamEvent
+}
+
+func NewStreamEvent(uuid, sessionID string, event map[string]any) *StreamEvent {
+	return &StreamEvent{UUID: uuid, SessionID: sessionID, Event: event}
+}
+
+// ErrorMessage reports a failure that ended the message stream early, such as an exceeded
+// budget or a CLI process that exited with an error. It is always the last message of the stream.
+type ErrorMessage struct {
+	// Err is the error that ended the stream.
+	Err error `json:"-"`
+}
+
+func (m *ErrorMessage) Type() MessageType {
+	return MessageTypeError
+}
+
+func (m *ErrorMessage) Error() string {
+	return m.Err.Error()
+}
+
+func (m *ErrorMessage) Unwrap() error {
+	return m.Err
+}
+
+func NewErrorMessage(err error) *ErrorMessage {
+	return &ErrorMessage{Err: err}
+}
+
 // TextBlock represents a plain text content block within a message.
 type TextBlock struct {
 	// Text contains the actual text content.
@@ -192,21 +318,16 @@ func NewToolResultBlock(toolUseID string, content any, isError *bool) *ToolResul
 	}
 }
 
-// McpServerConfig represents configuration for a Model Context Protocol (MCP) server.
-// MCP servers extend Claude Code's capabilities with additional tools and resources.
-type McpServerConfig struct {
-	// Type specifies the connection type: "stdio", "sse", or "http".
-	Type string `json:"type,omitempty"`
-	// Command is the executable command for stdio-type servers.
-	Command string `json:"command,omitempty"`
-	// Args contains command-line arguments for the MCP server executable.
-	Args []string `json:"args,omitempty"`
-	// Env contains environment variables to set for the MCP server process.
-	Env map[string]string `json:"env,omitempty"`
-	// URL is the endpoint URL for sse or http-type servers.
-	URL string `json:"url,omitempty"`
-	// Headers contains HTTP headers for sse or http-type servers.
-	Headers map[string]string `json:"headers,omitempty"`
+// AgentDefinition describes a custom subagent that Claude can delegate tasks to via the Task tool.
+type AgentDefinition struct {
+	// Description explains when the agent should be used.
+	Description string `json:"description"`
+	// Prompt is the system prompt of the agent.
+	Prompt string `json:"prompt"`
+	// Tools lists the tools available to the agent. If empty, the agent inherits all tools.
+	Tools []string `json:"tools,omitempty"`
+	// Model selects the model of the agent (e.g., "sonnet", "opus", "haiku", "inherit").
+	Model string `json:"model,omitempty"`
 }
 
 // QueryOptions contains configuration options for Claude Code queries.
@@ -220,7 +341,8 @@ type QueryOptions struct {
 	SystemPrompt string `json:"system_prompt,omitempty"`
 	// AppendSystemPrompt adds additional instructions to the default system prompt.
 	AppendSystemPrompt string `json:"append_system_prompt,omitempty"`
-	// McpTools is a list of MCP tool names to make available for this query.
+	// McpTools is a list of MCP tool names (e.g., "mcp__github__create_issue") to make available for this query.
+	// They are permitted in addition to AllowedTools.
 	McpTools []string `json:"mcp_tools,omitempty"`
 	// McpServers contains MCP server configurations to use for this query.
 	McpServers map[string]McpServerConfig `json:"mcp_servers,omitempty"`
@@ -239,15 +361,79 @@ type QueryOptions struct {
 	// PermissionPromptToolName customizes the tool name shown in permission prompts.
 	PermissionPromptToolName string `json:"permission_prompt_tool_name,omitempty"`
 	// CWD sets the current working directory for the Claude Code session.
+	// It overrides ClientOptions.CWD for this query.
 	CWD string `json:"cwd,omitempty"`
+	// IncludePartialMessages streams StreamEvent messages while responses are generated.
+	// It is ignored by CLI versions that do not support partial messages.
+	IncludePartialMessages bool `json:"include_partial_messages,omitempty"`
+	// AddDirs lists additional directories that Claude is allowed to access besides CWD.
+	AddDirs []string `json:"add_dirs,omitempty"`
+	// Settings is a path to a settings JSON file or an inline JSON settings string.
+	Settings string `json:"settings,omitempty"`
+	// SettingSources selects which settings files the CLI loads. If nil, the CLI default is used;
+	// a non-nil empty slice loads no settings files.
+	SettingSources []SettingSource `json:"setting_sources,omitempty"`
+	// FallbackModel is used automatically when the primary model is overloaded.
+	FallbackModel string `json:"fallback_model,omitempty"`
+	// User runs the CLI process as the given operating system user (name or numeric ID).
+	// This is only supported on Unix systems and usually requires elevated privileges.
+	// Temporary files the CLI reads, such as system prompts kept out of argv, are owned by this user.
+	User string `json:"user,omitempty"`
+	// StrictMcpConfig makes the CLI use only McpServers, ignoring all other MCP configurations.
+	StrictMcpConfig bool `json:"strict_mcp_config,omitempty"`
+	// Agents defines custom subagents keyed by agent name.
+	Agents map[string]AgentDefinition `json:"agents,omitempty"`
+	// MaxBudgetUSD stops the query once its estimated cost exceeds this amount in USD.
+	// The cost is estimated from the usage of each assistant message as the stream progresses.
+	MaxBudgetUSD float64 `json:"max_budget_usd,omitempty"`
+	// MaxTokens stops the query once its total token usage, including cached input tokens, exceeds this count.
+	MaxTokens int `json:"max_tokens,omitempty"`
+	// Budget is a spending limit shared with other queries. The query is refused if the budget
+	// is depleted, is stopped once it exceeds its reservation, and is charged when it ends.
+	// It reserves MaxBudgetUSD and MaxTokens, capped by what remains, or half of what remains
+	// for a limit it does not set.
+	Budget *Budget `json:"-"`
+	// Retry retries the query when it fails for a transient reason, resuming its session
+	// where possible. If nil, failed queries are not retried.
+	Retry *RetryPolicy `json:"-"`
 }
 
 // ClientOptions contains configuration options for creating a new Claude Code SDK client.
 type ClientOptions struct {
 	// CLIPath specifies a custom path to the Claude Code CLI executable.
 	// If empty, the SDK will search for the CLI in standard locations.
+	// A JavaScript entry point (e.g., a local cli.js) is run through NodePath.
 	CLIPath string
+	// NodePath specifies the Node.js binary used to run a JavaScript CLIPath.
+	// If empty, "node" is looked up in PATH.
+	NodePath string
 	// CWD sets the current working directory for all operations.
 	// If empty, the current process working directory is used.
 	CWD string
+	// PromptDelivery controls whether prompts are passed via argv or stdin.
+	// If empty, PromptDeliveryAuto is used.
+	PromptDelivery PromptDelivery
+	// McpConfigDelivery controls whether MCP server configurations are passed via argv or a temporary file.
+	// If empty, McpConfigDeliveryAuto is used.
+	McpConfigDelivery McpConfigDelivery
+	// SkipVersionCheck disables CLI version detection on connect.
+	// When set, all optional CLI features are assumed to be available.
+	SkipVersionCheck bool
+	// RateLimiter delays the start of queries to keep each model within its rate limit.
+	// Share one RateLimiter between all clients that use the same API key.
+	RateLimiter *RateLimiter
+	// Observer is notified about every query of the client, for instrumentation such as tracing.
+	Observer Observer
+	// Metrics receives measurements of the client's queries and CLI processes for monitoring.
+	Metrics Metrics
+	// Logger receives CLI process lifecycle events, CLI error output, skipped output lines,
+	// retries, and rate limit pauses. If nil, nothing is logged.
+	Logger *slog.Logger
+	// Redactor scrubs secrets from log records and process errors. The environment variables
+	// and headers of each query's MCP servers are always redacted as well.
+	// If nil, a Redactor with the default patterns is used.
+	Redactor *Redactor
+	// QueryDefaults are applied to every query of the client.
+	// Per-query options are merged on top of them (see QueryOptions.Merge).
+	QueryDefaults *QueryOptions
 }
@@ -0,0 +1,131 @@
+package types
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+)
+
+// Validate checks the options for invalid values and combinations that the CLI would
+// otherwise only reject after starting up. It returns nil if the options are valid, or
+// the errors.Join of one *errors.ValidationError per problem found.
+func (o *QueryOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+
+	var errs []error
+	fail := func(field string, format string, args ...any) {
+		errs = append(errs, errors.NewValidationError("QueryOptions."+field, fmt.Sprintf(format, args...)))
+	}
+
+	if o.ContinueConversation && o.Resume != "" {
+		fail("Resume", "cannot be combined with ContinueConversation")
+	}
+
+	for _, tool := range o.DisallowedTools {
+		if slices.Contains(o.AllowedTools, tool) {
+			fail("DisallowedTools", "tool %q is also listed in AllowedTools", tool)
+		}
+	}
+
+	if o.PermissionMode != "" && !o.PermissionMode.IsValid() {
+		fail("PermissionMode", "unknown permission mode %q", o.PermissionMode)
+	}
+
+	if o.MaxTurns < 0 {
+		fail("MaxTurns", "must not be negative, got %d", o.MaxTurns)
+	}
+	if o.MaxBudgetUSD < 0 {
+		fail("MaxBudgetUSD", "must not be negative, got %g", o.MaxBudgetUSD)
+	}
+	if o.MaxTokens < 0 {
+		fail("MaxTokens", "must not be negative, got %d", o.MaxTokens)
+	}
+	if o.MaxThinkingTokens < 0 {
+		fail("MaxThinkingTokens", "must not be negative, got %d", o.MaxThinkingTokens)
+	}
+
+	for i, dir := range o.AddDirs {
+		if dir == "" {
+			fail(fmt.Sprintf("AddDirs[%d]", i), "must not be empty")
+		}
+	}
+
+	for i, source := range o.SettingSources {
+		switch source {
+		case SettingSourceUser, SettingSourceProject, SettingSourceLocal:
+		default:
+			fail(fmt.Sprintf("SettingSources[%d]", i), "unknown setting source %q", source)
+		}
+	}
+
+	for _, name := range sortedKeys(o.McpServers) {
+		server := o.McpServers[name]
+		field := fmt.Sprintf("McpServers[%q]", name)
+		switch s := server.(type) {
+		case nil:
+			fail(field, "is nil")
+		case McpStdioServer:
+			if s.Command == "" {
+				fail(field+".Command", "is required for stdio servers")
+			}
+		case McpSSEServer:
+			validateMcpURL(field, s.URL, fail)
+		case McpHTTPServer:
+			validateMcpURL(field, s.URL, fail)
+		case McpSDKServer:
+			fail(field, "in-process SDK servers are not supported")
+		default:
+			fail(field, "unsupported server configuration %T (want McpStdioServer, McpSSEServer, or McpHTTPServer)", server)
+		}
+	}
+
+	for _, name := range sortedKeys(o.Agents) {
+		agent := o.Agents[name]
+		field := fmt.Sprintf("Agents[%q]", name)
+		if agent.Description == "" {
+			fail(field+".Description", "is required")
+		}
+		if agent.Prompt == "" {
+			fail(field+".Prompt", "is required")
+		}
+	}
+
+	return stderrors.Join(errs...)
+}
+
+// validateMcpURL checks the URL of a remote MCP server.
+func validateMcpURL(field string, rawURL string, fail func(field string, format string, args ...any)) {
+	if rawURL == "" {
+		fail(field+".URL", "is required for remote servers")
+		return
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		fail(field+".URL", "must be an absolute http or https URL, got %q", rawURL)
+	}
+}
+
+// IsValid reports whether m is one of the permission modes supported by the CLI.
+func (m PermissionMode) IsValid() bool {
+	switch m {
+	case PermissionModeDefault, PermissionModeAcceptEdits, PermissionModeBypassPermissions, PermissionModePlan:
+		return true
+	default:
+		return false
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
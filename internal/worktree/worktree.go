@@ -0,0 +1,142 @@
+// Package worktree manages temporary git worktrees that isolate concurrent agent runs.
+package worktree
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+	"github.com/musaprg/claude-code-sdk-go/internal/git"
+)
+
+// Worktree is a temporary git worktree checked out on its own branch.
+type Worktree struct {
+	// Repo is the root of the repository the worktree belongs to.
+	Repo string
+	// Path is the directory of the worktree.
+	Path string
+	// Branch is the branch checked out in the worktree.
+	Branch string
+	// Base is the commit the branch was created from.
+	Base string
+}
+
+// repoLocks serializes changes to the worktree list of each repository, keyed by the absolute
+// path of the repository's common git directory, which its main and linked worktrees share.
+// Git does not support adding or removing worktrees of one repository concurrently.
+var repoLocks sync.Map
+
+// lockRepo locks the worktree list of the repository containing dir and returns the unlock function.
+func lockRepo(ctx context.Context, dir string) func() {
+	mu, _ := repoLocks.LoadOrStore(repoKey(ctx, dir), &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// repoKey returns the absolute path of the common git directory of the repository containing
+// dir, or dir itself if it cannot be determined.
+func repoKey(ctx context.Context, dir string) string {
+	commonDir, err := git.Run(ctx, dir, nil, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return dir
+	}
+	// The common directory is reported relative to dir unless it lies elsewhere
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(dir, commonDir)
+	}
+	if resolved, err := filepath.EvalSymlinks(commonDir); err == nil {
+		commonDir = resolved
+	}
+	return filepath.Clean(commonDir)
+}
+
+// Add creates a worktree of repo in a new temporary directory, checked out on a new branch
+// named branchPrefix followed by a random suffix. The branch starts at base, or HEAD if base is empty.
+func Add(ctx context.Context, repo string, base string, branchPrefix string) (*Worktree, error) {
+	root, err := git.Run(ctx, repo, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, errors.NewWorktreeError("not a git repository", repo, "", err)
+	}
+	if base == "" {
+		base = "HEAD"
+	}
+	baseCommit, err := git.Run(ctx, root, nil, "rev-parse", "--verify", base+"^{commit}")
+	if err != nil {
+		return nil, errors.NewWorktreeError("failed to resolve base revision "+base, root, "", err)
+	}
+
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, errors.NewWorktreeError("failed to generate branch name", root, "", err)
+	}
+	branch := branchPrefix + hex.EncodeToString(suffix)
+
+	path, err := os.MkdirTemp("", "claude-worktree-*")
+	if err != nil {
+		return nil, errors.NewWorktreeError("failed to create worktree directory", root, "", err)
+	}
+	unlock := lockRepo(ctx, root)
+	_, err = git.Run(ctx, root, nil, "worktree", "add", "-q", "-b", branch, path, baseCommit)
+	unlock()
+	if err != nil {
+		os.RemoveAll(path)
+		return nil, errors.NewWorktreeError("failed to add worktree", root, path, err)
+	}
+
+	return &Worktree{Repo: root, Path: path, Branch: branch, Base: baseCommit}, nil
+}
+
+// Diff stages every change in the worktree and returns a binary-safe diff of the
+// worktree against Base, including any commits made on Branch.
+func (w *Worktree) Diff(ctx context.Context) (string, error) {
+	if _, err := git.Run(ctx, w.Path, nil, "add", "-A"); err != nil {
+		return "", errors.NewWorktreeError("failed to stage worktree changes", w.Repo, w.Path, err)
+	}
+	diff, err := git.Output(ctx, w.Path, nil, "diff", "--cached", "--binary", w.Base)
+	if err != nil {
+		return "", errors.NewWorktreeError("failed to diff worktree", w.Repo, w.Path, err)
+	}
+	return diff, nil
+}
+
+// Commit commits all changes in the worktree to Branch and returns the resulting commit.
+// If there is nothing to commit, the current commit of Branch is returned.
+func (w *Worktree) Commit(ctx context.Context, message string) (string, error) {
+	if _, err := git.Run(ctx, w.Path, nil, "add", "-A"); err != nil {
+		return "", errors.NewWorktreeError("failed to stage worktree changes", w.Repo, w.Path, err)
+	}
+	if _, err := git.Run(ctx, w.Path, nil, "diff", "--cached", "--quiet"); err != nil {
+		if _, err := git.Run(ctx, w.Path, nil, "commit", "-q", "-m", message); err != nil {
+			return "", errors.NewWorktreeError("failed to commit worktree changes", w.Repo, w.Path, err)
+		}
+	}
+	commit, err := git.Run(ctx, w.Path, nil, "rev-parse", "HEAD")
+	if err != nil {
+		return "", errors.NewWorktreeError("failed to resolve worktree commit", w.Repo, w.Path, err)
+	}
+	return commit, nil
+}
+
+// Remove deletes the worktree directory and, unless keepBranch is set, its branch.
+func (w *Worktree) Remove(ctx context.Context, keepBranch bool) error {
+	unlock := lockRepo(ctx, w.Repo)
+	defer unlock()
+	if _, err := git.Run(ctx, w.Repo, nil, "worktree", "remove", "--force", w.Path); err != nil {
+		// Fall back to deleting the directory and pruning git's bookkeeping
+		os.RemoveAll(w.Path)
+		if _, pruneErr := git.Run(ctx, w.Repo, nil, "worktree", "prune"); pruneErr != nil {
+			return errors.NewWorktreeError("failed to remove worktree", w.Repo, w.Path, err)
+		}
+	}
+	if keepBranch {
+		return nil
+	}
+	if _, err := git.Run(ctx, w.Repo, nil, "branch", "-q", "-D", w.Branch); err != nil {
+		return errors.NewWorktreeError("failed to delete branch "+w.Branch, w.Repo, w.Path, err)
+	}
+	return nil
+}
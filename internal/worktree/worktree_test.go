@@ -0,0 +1,76 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepoKeySharedByLinkedWorktrees(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "initial"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	ctx := context.Background()
+	worktree, err := Add(ctx, repo, "", "test/")
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	defer worktree.Remove(ctx, false)
+
+	if main, linked := repoKey(ctx, repo), repoKey(ctx, worktree.Path); main != linked {
+		t.Errorf("repoKey() = %q for the repository and %q for its linked worktree, want the same key", main, linked)
+	}
+}
+
+func TestDiffAppliesWithTrailingBlankContext(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, "notes.txt"), []byte("first\nsecond\n\n\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "notes.txt"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	ctx := context.Background()
+	worktree, err := Add(ctx, repo, "", "test/")
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	defer worktree.Remove(ctx, false)
+	// The hunk ends in the file's trailing blank lines
+	if err := os.WriteFile(filepath.Join(worktree.Path, "notes.txt"), []byte("first\nchanged\n\n\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := worktree.Diff(ctx)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	apply := exec.Command("git", "-C", repo, "apply", "--check", "-")
+	apply.Stdin = strings.NewReader(diff)
+	if out, err := apply.CombinedOutput(); err != nil {
+		t.Errorf("git apply --check: %v: %s\ndiff:\n%s", err, out, diff)
+	}
+}
@@ -0,0 +1,24 @@
+package claudecode
+
+import "github.com/musaprg/claude-code-sdk-go/internal/types"
+
+// ProjectMcpConfigFile is the name of the file that holds the shared MCP servers of a project.
+const ProjectMcpConfigFile = types.ProjectMcpConfigFile
+
+// Re-export MCP configuration loading from internal package.
+// Loaded servers can be added to queries with WithMCPServers or QueryOptions.McpServers.
+var (
+	// LoadMcpConfig reads the MCP servers from a .mcp.json or claude_desktop_config.json style
+	// file, expanding ${VAR} and ${VAR:-default} references to environment variables.
+	LoadMcpConfig = types.LoadMcpConfig
+	// LoadProjectMcpConfig reads the MCP servers from the .mcp.json file of a project directory.
+	LoadProjectMcpConfig = types.LoadProjectMcpConfig
+	// ParseMcpConfig parses MCP servers from configuration file contents, looking up
+	// referenced environment variables with the given function, or os.LookupEnv if it is nil.
+	ParseMcpConfig = types.ParseMcpConfig
+	// ParseMcpServerConfig decodes the JSON configuration of a single MCP server into the
+	// McpServerConfig variant named by its "type". Unknown types are rejected.
+	ParseMcpServerConfig = types.ParseMcpServerConfig
+	// ClaudeDesktopConfigPath returns the location of the current user's Claude Desktop configuration file.
+	ClaudeDesktopConfigPath = types.ClaudeDesktopConfigPath
+)
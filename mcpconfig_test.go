@@ -0,0 +1,198 @@
+package claudecode
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadMcpConfig(t *testing.T) {
+	dir := t.TempDir()
+	config := `{
+  "mcpServers": {
+    "github": {
+      "command": "${MCP_TEST_BIN:-npx}",
+      "args": ["-y", "@modelcontextprotocol/server-github"],
+      "env": {"GITHUB_TOKEN": "${MCP_TEST_TOKEN}"}
+    },
+    "api": {
+      "type": "http",
+      "url": "${MCP_TEST_HOST}/mcp",
+      "headers": {"Authorization": "Bearer ${MCP_TEST_TOKEN}"}
+    }
+  },
+  "globalShortcut": "Ctrl+Space"
+}`
+	if err := os.WriteFile(filepath.Join(dir, ProjectMcpConfigFile), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MCP_TEST_TOKEN", "tok123")
+	t.Setenv("MCP_TEST_HOST", "https://mcp.example.com")
+
+	servers, err := LoadProjectMcpConfig(dir)
+	if err != nil {
+		t.Fatalf("LoadProjectMcpConfig() error = %v", err)
+	}
+	want := map[string]McpServerConfig{
+		"github": McpStdioServer{
+			Command: "npx",
+			Args:    []string{"-y", "@modelcontextprotocol/server-github"},
+			Env:     map[string]string{"GITHUB_TOKEN": "tok123"},
+		},
+		"api": McpHTTPServer{
+			URL:     "https://mcp.example.com/mcp",
+			Headers: map[string]string{"Authorization": "Bearer tok123"},
+		},
+	}
+	if !reflect.DeepEqual(servers, want) {
+		t.Errorf("LoadProjectMcpConfig() = %+v, want %+v", servers, want)
+	}
+
+	options := NewQueryOptions(WithMCPServers(servers))
+	if err := options.Validate(); err != nil {
+		t.Errorf("loaded servers are invalid: %v", err)
+	}
+}
+
+func TestLoadMcpConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	missingVars := filepath.Join(dir, "missing.json")
+	os.WriteFile(missingVars, []byte(`{"mcpServers":{"s":{"command":"${MCP_TEST_UNSET_B}","args":["${MCP_TEST_UNSET_A}"]}}}`), 0o600)
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte(`{"mcpServers":`), 0o600)
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "missing file", path: filepath.Join(dir, "absent.json")},
+		{name: "invalid JSON", path: invalid},
+		{name: "unset variables", path: missingVars,
+			want: missingVars + ": environment variables not set: MCP_TEST_UNSET_A, MCP_TEST_UNSET_B"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadMcpConfig(tt.path)
+			var configErr *McpConfigError
+			if !errors.As(err, &configErr) {
+				t.Fatalf("LoadMcpConfig() error = %v, want *McpConfigError", err)
+			}
+			if configErr.Path != tt.path {
+				t.Errorf("McpConfigError.Path = %q, want %q", configErr.Path, tt.path)
+			}
+			if tt.want != "" && err.Error() != tt.want {
+				t.Errorf("LoadMcpConfig() error = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMcpConfigLookupEnv(t *testing.T) {
+	env := map[string]string{"EMPTY": "", "KEY": "k"}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	servers, err := ParseMcpConfig([]byte(`{"mcpServers":{"s":{"command":"run","args":["${KEY}","${EMPTY}","${EMPTY:-fallback}","$KEY"]}}}`), lookup)
+	if err != nil {
+		t.Fatalf("ParseMcpConfig() error = %v", err)
+	}
+	want := []string{"k", "", "fallback", "$KEY"}
+	if got := servers["s"].(McpStdioServer).Args; !reflect.DeepEqual(got, want) {
+		t.Errorf("Args = %q, want %q", got, want)
+	}
+}
+
+func TestMcpServerConfigJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		server McpServerConfig
+		want   string
+	}{
+		{
+			name:   "stdio",
+			server: McpStdioServer{Command: "mcp-github", Args: []string{"--stdio"}, Env: map[string]string{"TOKEN": "t"}},
+			want:   `{"type":"stdio","command":"mcp-github","args":["--stdio"],"env":{"TOKEN":"t"}}`,
+		},
+		{
+			name:   "sse",
+			server: McpSSEServer{URL: "https://mcp.example.com/sse"},
+			want:   `{"type":"sse","url":"https://mcp.example.com/sse"}`,
+		},
+		{
+			name:   "http",
+			server: McpHTTPServer{URL: "https://mcp.example.com/mcp", Headers: map[string]string{"Authorization": "Bearer t"}},
+			want:   `{"type":"http","url":"https://mcp.example.com/mcp","headers":{"Authorization":"Bearer t"}}`,
+		},
+		{
+			name:   "sdk",
+			server: McpSDKServer{Name: "tools"},
+			want:   `{"type":"sdk","name":"tools"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.server)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("json.Marshal() = %s, want %s", data, tt.want)
+			}
+
+			parsed, err := ParseMcpServerConfig(data)
+			if err != nil {
+				t.Fatalf("ParseMcpServerConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(parsed, tt.server) {
+				t.Errorf("ParseMcpServerConfig() = %#v, want %#v", parsed, tt.server)
+			}
+		})
+	}
+
+	if parsed, err := ParseMcpServerConfig([]byte(`{"command":"mcp-local"}`)); err != nil || !reflect.DeepEqual(parsed, McpStdioServer{Command: "mcp-local"}) {
+		t.Errorf("ParseMcpServerConfig() without type = %#v, %v, want an McpStdioServer", parsed, err)
+	}
+
+	_, err := ParseMcpServerConfig([]byte(`{"type":"htpp","url":"https://mcp.example.com"}`))
+	var configErr *McpConfigError
+	if !errors.As(err, &configErr) || !strings.Contains(err.Error(), `unknown MCP server type "htpp"`) {
+		t.Errorf("ParseMcpServerConfig() with unknown type error = %v, want *McpConfigError", err)
+	}
+}
+
+func TestQueryOptionsJSON(t *testing.T) {
+	options := NewQueryOptions(
+		WithModel("sonnet"),
+		WithContinueConversation(),
+		WithMCPServers(map[string]McpServerConfig{
+			"github": McpStdioServer{Command: "mcp-github", Env: map[string]string{"TOKEN": "t"}},
+			"events": McpSSEServer{URL: "https://mcp.example.com/sse"},
+			"api":    McpHTTPServer{URL: "https://mcp.example.com/mcp", Headers: map[string]string{"Authorization": "Bearer t"}},
+		}),
+	)
+
+	data, err := json.Marshal(options)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded QueryOptions
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, options) {
+		t.Errorf("json.Unmarshal() = %+v, want %+v", &decoded, options)
+	}
+
+	err = json.Unmarshal([]byte(`{"mcp_servers":{"bad":{"type":"websocket"}}}`), &decoded)
+	var configErr *McpConfigError
+	if !errors.As(err, &configErr) || !strings.Contains(err.Error(), `server "bad"`) {
+		t.Errorf("json.Unmarshal() with unknown server type error = %v, want *McpConfigError", err)
+	}
+}
@@ -0,0 +1,130 @@
+package claudecode
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/budget"
+	"github.com/musaprg/claude-code-sdk-go/internal/types"
+)
+
+// Metrics receives measurements of a client's queries and CLI processes for monitoring.
+// See the claudeprom package for a Prometheus implementation.
+type Metrics = types.Metrics
+
+// Reasons a query failed, as reported to Metrics.QueryFinished.
+const (
+	FailureStart    = types.FailureStart
+	FailureProcess  = types.FailureProcess
+	FailureResult   = types.FailureResult
+	FailureBudget   = types.FailureBudget
+	FailureCanceled = types.FailureCanceled
+	FailureOther    = types.FailureOther
+)
+
+// metricsObserver reports the measurements of a single query to Metrics.
+type metricsObserver struct {
+	metrics Metrics
+	model   string
+	started time.Time
+	// received is set once the first message has arrived.
+	received bool
+	// tools maps the IDs of pending tool calls to tool names.
+	tools map[string]string
+	// meters tracks usage per responding model, and meter across all of them.
+	meters map[string]*budget.Meter
+	meter  *budget.Meter
+	result *ResultMessage
+}
+
+func startMetrics(metrics Metrics, model string) *metricsObserver {
+	metrics.QueryStarted(model)
+	return &metricsObserver{
+		metrics: metrics,
+		model:   model,
+		started: time.Now(),
+		tools:   make(map[string]string),
+		meters:  make(map[string]*budget.Meter),
+		meter:   budget.NewMeter(),
+	}
+}
+
+func (o *metricsObserver) Message(message Message) {
+	if !o.received {
+		o.received = true
+		o.metrics.FirstMessage(o.model, time.Since(o.started))
+	}
+
+	switch msg := message.(type) {
+	case *AssistantMessage:
+		if msg.Usage != nil {
+			meter, ok := o.meters[msg.Model]
+			if !ok {
+				meter = budget.NewMeter()
+				o.meters[msg.Model] = meter
+			}
+			meter.Record(msg.ID, msg.Model, *msg.Usage)
+			o.meter.Record(msg.ID, msg.Model, *msg.Usage)
+		}
+		for _, block := range msg.Content {
+			if toolUse, ok := block.(*ToolUseBlock); ok {
+				o.tools[toolUse.ID] = toolUse.Name
+			}
+		}
+	case *UserMessage:
+		for _, block := range msg.ContentBlocks {
+			toolResult, ok := block.(*ToolResultBlock)
+			if !ok {
+				continue
+			}
+			if name, ok := o.tools[toolResult.ToolUseID]; ok {
+				delete(o.tools, toolResult.ToolUseID)
+				o.metrics.ToolCall(name, toolResult.IsError != nil && *toolResult.IsError)
+			}
+		}
+	case *ResultMessage:
+		o.result = msg
+	}
+}
+
+func (o *metricsObserver) End(err error) {
+	// The cost reported by the ResultMessage covers every model, so it is split between them
+	// in proportion to their estimated costs
+	costUSD, _ := querySpend(o.meter, o.result)
+	estimatedUSD := o.meter.CostUSD()
+	for model, meter := range o.meters {
+		modelCostUSD := meter.CostUSD()
+		switch {
+		case len(o.meters) == 1:
+			modelCostUSD = costUSD
+		case estimatedUSD > 0:
+			modelCostUSD *= costUSD / estimatedUSD
+		}
+		o.metrics.Usage(model, meter.Usage(), modelCostUSD)
+	}
+	o.metrics.QueryFinished(o.model, time.Since(o.started), o.failure(err))
+}
+
+// failure classifies how the query ended, as one of the Failure constants or "".
+func (o *metricsObserver) failure(err error) string {
+	var processErr *ProcessError
+	var budgetErr *BudgetExceededError
+	switch {
+	case err == nil && o.result != nil && o.result.IsError:
+		return FailureResult
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return FailureCanceled
+	case !o.received:
+		// Queries that fail to start never produce a message
+		return FailureStart
+	case errors.As(err, &budgetErr):
+		return FailureBudget
+	case errors.As(err, &processErr):
+		return FailureProcess
+	default:
+		return FailureOther
+	}
+}
@@ -0,0 +1,123 @@
+package claudecode
+
+import (
+	"context"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingMetrics records the failure reasons of finished queries and the cost per model.
+type recordingMetrics struct {
+	mu       sync.Mutex
+	finished []string
+	costUSD  map[string]float64
+}
+
+func (m *recordingMetrics) QueryStarted(model string) {}
+func (m *recordingMetrics) QueryFinished(model string, duration time.Duration, failure string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.finished = append(m.finished, failure)
+}
+func (m *recordingMetrics) ProcessStarted(latency time.Duration)             {}
+func (m *recordingMetrics) FirstMessage(model string, latency time.Duration) {}
+func (m *recordingMetrics) ToolCall(tool string, isError bool)               {}
+func (m *recordingMetrics) Usage(model string, usage Usage, costUSD float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.costUSD == nil {
+		m.costUSD = make(map[string]float64)
+	}
+	m.costUSD[model] += costUSD
+}
+func (m *recordingMetrics) StderrTruncated() {}
+
+func TestClientMetricsFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		cliPath func(t *testing.T) string
+		options *QueryOptions
+		want    string
+	}{
+		{name: "success", cliPath: func(t *testing.T) string { return writeFlakyCLI(t, t.TempDir(), 0, "") }, want: ""},
+		{name: "process", cliPath: func(t *testing.T) string { return writeFlakyCLI(t, t.TempDir(), 1, "boom") }, want: FailureProcess},
+		{name: "budget", cliPath: writeSpendingCLI, options: NewQueryOptions(WithMaxTokens(1500)), want: FailureBudget},
+		{name: "start", cliPath: func(t *testing.T) string { return "/nonexistent/claude" }, want: FailureStart},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &recordingMetrics{}
+			client := NewClient(WithCLIPath(tt.cliPath(t)), WithMetrics(metrics))
+			if messages, err := client.Query(context.Background(), "work", tt.options); err == nil {
+				for range messages {
+				}
+			}
+
+			// The query is reported as finished after its channel is closed
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				metrics.mu.Lock()
+				finished := append([]string(nil), metrics.finished...)
+				metrics.mu.Unlock()
+				if len(finished) == 1 {
+					if finished[0] != tt.want {
+						t.Errorf("failure = %q, want %q", finished[0], tt.want)
+					}
+					return
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("QueryFinished was called %d times, want 1", len(finished))
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestClientMetricsReportedCost(t *testing.T) {
+	// The CLI reports a total of $0.09018, three times the estimate of $0.01503 per message
+	path := filepath.Join(t.TempDir(), "fake-claude")
+	script := `#!/bin/sh
+if [ "$1" = "--version" ]; then echo "2.0.0 (Claude Code)"; exit 0; fi
+echo '{"type":"assistant","message":{"id":"msg_1","model":"claude-sonnet-4-5","content":[{"type":"text","text":"a"}],"usage":{"input_tokens":10,"output_tokens":1000}}}'
+echo '{"type":"assistant","message":{"id":"msg_2","model":"claude-sonnet-4-0","content":[{"type":"text","text":"b"}],"usage":{"input_tokens":10,"output_tokens":1000}}}'
+echo '{"type":"result","subtype":"success","duration_ms":10,"duration_api_ms":5,"is_error":false,"num_turns":1,"session_id":"s1","total_cost_usd":0.09018}'
+`
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := &recordingMetrics{}
+	client := NewClient(WithCLIPath(path), WithMetrics(metrics))
+	messages, err := client.Query(context.Background(), "work", nil)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	for range messages {
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		metrics.mu.Lock()
+		finished, costUSD := len(metrics.finished), maps.Clone(metrics.costUSD)
+		metrics.mu.Unlock()
+		if finished == 1 {
+			for _, model := range []string{"claude-sonnet-4-5", "claude-sonnet-4-0"} {
+				if math.Abs(costUSD[model]-0.04509) > 1e-9 {
+					t.Errorf("cost of %s = %v, want 0.04509", model, costUSD[model])
+				}
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the query was not reported as finished")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
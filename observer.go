@@ -0,0 +1,95 @@
+package claudecode
+
+import (
+	"context"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/types"
+)
+
+// Re-export instrumentation hooks from internal package.
+type (
+	// Observer is notified about every query of a client, for instrumentation such as tracing.
+	// See the claudeotel package for an OpenTelemetry implementation.
+	Observer = types.Observer
+	// QueryObserver follows a single query.
+	QueryObserver = types.QueryObserver
+)
+
+// startObserver notifies the client's observer and metrics that a query starts. It returns
+// nil if neither is set.
+func (c *Client) startObserver(ctx context.Context, options *QueryOptions) QueryObserver {
+	var observers queryObservers
+	if c.metrics != nil {
+		observers = append(observers, startMetrics(c.metrics, options.Model))
+	}
+	if c.observer != nil {
+		if observer := c.observer.StartQuery(ctx, options); observer != nil {
+			observers = append(observers, observer)
+		}
+	}
+
+	switch len(observers) {
+	case 0:
+		return nil
+	case 1:
+		return observers[0]
+	default:
+		return observers
+	}
+}
+
+// queryObservers passes the events of a query to several observers.
+type queryObservers []QueryObserver
+
+func (o queryObservers) Message(message Message) {
+	for _, observer := range o {
+		observer.Message(message)
+	}
+}
+
+func (o queryObservers) End(err error) {
+	for _, observer := range o {
+		observer.End(err)
+	}
+}
+
+// endObserver ends a query that failed to start.
+func endObserver(observer QueryObserver, err error) {
+	if observer != nil {
+		observer.End(err)
+	}
+}
+
+// observe passes the messages of a query through observer on their way to the caller.
+func observe(ctx context.Context, observer QueryObserver, messageCh <-chan Message) <-chan Message {
+	if observer == nil {
+		return messageCh
+	}
+
+	wrappedCh := make(chan Message, 10)
+	go func() {
+		defer close(wrappedCh)
+
+		var err error
+		defer func() {
+			if err == nil {
+				err = ctx.Err()
+			}
+			observer.End(err)
+		}()
+
+		for message := range messageCh {
+			if msg, ok := message.(*ErrorMessage); ok {
+				err = msg.Err
+			}
+			observer.Message(message)
+			select {
+			case wrappedCh <- message:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return wrappedCh
+}
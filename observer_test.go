@@ -0,0 +1,90 @@
+package claudecode
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingObserver records the queries it observes.
+type recordingObserver struct {
+	mu      sync.Mutex
+	options []*QueryOptions
+	queries []*recordingQuery
+}
+
+type recordingQuery struct {
+	messages []Message
+	ended    chan error
+}
+
+func (o *recordingObserver) StartQuery(ctx context.Context, options *QueryOptions) QueryObserver {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	query := &recordingQuery{ended: make(chan error, 1)}
+	o.options = append(o.options, options)
+	o.queries = append(o.queries, query)
+	return query
+}
+
+func (q *recordingQuery) Message(message Message) { q.messages = append(q.messages, message) }
+func (q *recordingQuery) End(err error)           { q.ended <- err }
+
+func TestClientObserver(t *testing.T) {
+	observer := &recordingObserver{}
+
+	t.Run("completed query", func(t *testing.T) {
+		client := NewClient(WithCLIPath(writeFlakyCLI(t, t.TempDir(), 0, "")), WithObserver(observer), WithModel("sonnet"))
+		messages, err := client.Query(context.Background(), "work", nil)
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		var received int
+		for range messages {
+			received++
+		}
+
+		query := observer.queries[0]
+		if err := <-query.ended; err != nil {
+			t.Errorf("End() error = %v, want nil", err)
+		}
+		if len(query.messages) != received {
+			t.Errorf("observer saw %d messages, caller received %d", len(query.messages), received)
+		}
+		if observer.options[0].Model != "sonnet" {
+			t.Errorf("observer options model = %q, want the merged client default", observer.options[0].Model)
+		}
+	})
+
+	t.Run("failed process", func(t *testing.T) {
+		client := NewClient(WithCLIPath(writeFlakyCLI(t, t.TempDir(), 1, "boom")), WithObserver(observer))
+		messages, err := client.Query(context.Background(), "work", nil)
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		for range messages {
+		}
+
+		select {
+		case err := <-observer.queries[1].ended:
+			var processErr *ProcessError
+			if !errors.As(err, &processErr) {
+				t.Errorf("End() error = %v, want a *ProcessError", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("End() was not called")
+		}
+	})
+
+	t.Run("start failure", func(t *testing.T) {
+		client := NewClient(WithCLIPath("/nonexistent/claude"), WithObserver(observer))
+		if _, err := client.Query(context.Background(), "work", nil); err == nil {
+			t.Fatal("Query() with a missing CLI should fail")
+		}
+		if err := <-observer.queries[2].ended; err == nil {
+			t.Error("End() error = nil, want the start error")
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package claudecode
+
+import "github.com/musaprg/claude-code-sdk-go/internal/types"
+
+// Re-export functional options from internal package.
+// Client options configure how the CLI is launched; query options set per-client defaults
+// when passed to NewClient, or build per-query options with NewQueryOptions.
+type (
+	// ClientOption configures a Client created with NewClient. *ClientOptions implements ClientOption.
+	ClientOption = types.ClientOption
+	// ClientOptionFunc adapts a function to the ClientOption interface.
+	ClientOptionFunc = types.ClientOptionFunc
+	// QueryOption sets a query option, either as a client default or for a single query.
+	QueryOption = types.QueryOption
+)
+
+var (
+	// NewQueryOptions builds QueryOptions from functional options.
+	NewQueryOptions = types.NewQueryOptions
+
+	// WithCLIPath sets the path to the Claude Code CLI executable.
+	WithCLIPath = types.WithCLIPath
+	// WithNodePath sets the Node.js binary used to run a JavaScript CLI entry point.
+	WithNodePath = types.WithNodePath
+	// WithCWD sets the working directory for all queries of the client.
+	WithCWD = types.WithCWD
+	// WithPromptDelivery controls whether prompts are passed via argv or stdin.
+	WithPromptDelivery = types.WithPromptDelivery
+	// WithMcpConfigDelivery controls whether MCP server configurations are passed via argv or a temporary file.
+	WithMcpConfigDelivery = types.WithMcpConfigDelivery
+	// WithSkipVersionCheck disables CLI version detection on connect.
+	WithSkipVersionCheck = types.WithSkipVersionCheck
+	// WithRateLimiter delays the start of queries to keep each model within its rate limit.
+	WithRateLimiter = types.WithRateLimiter
+	// WithObserver notifies an Observer about every query of the client.
+	WithObserver = types.WithObserver
+	// WithMetrics reports measurements of the client's queries and CLI processes to a Metrics implementation.
+	WithMetrics = types.WithMetrics
+	// WithLogger logs CLI process lifecycle events, CLI error output, and SDK decisions to a *slog.Logger.
+	WithLogger = types.WithLogger
+	// WithRedactor scrubs secrets matched by a *Redactor from log records and process errors.
+	WithRedactor = types.WithRedactor
+
+	// WithModel sets the model to use.
+	WithModel = types.WithModel
+	// WithFallbackModel sets the model used when the primary model is overloaded.
+	WithFallbackModel = types.WithFallbackModel
+	// WithTools adds tools to the list of allowed tools.
+	WithTools = types.WithTools
+	// WithDisallowedTools adds tools to the list of prohibited tools.
+	WithDisallowedTools = types.WithDisallowedTools
+	// WithMCPServer adds or replaces the MCP server with the given name.
+	WithMCPServer = types.WithMCPServer
+	// WithMCPServers adds or replaces MCP servers, such as those loaded with LoadMcpConfig.
+	WithMCPServers = types.WithMCPServers
+	// WithMCPTools adds MCP tools to the list of allowed tools.
+	WithMCPTools = types.WithMCPTools
+	// WithPermissionMode sets how tool permissions are handled.
+	WithPermissionMode = types.WithPermissionMode
+	// WithSystemPrompt replaces the default system prompt.
+	WithSystemPrompt = types.WithSystemPrompt
+	// WithAppendSystemPrompt adds instructions to the default system prompt.
+	WithAppendSystemPrompt = types.WithAppendSystemPrompt
+	// WithMaxTurns limits the number of conversation turns.
+	WithMaxTurns = types.WithMaxTurns
+	// WithMaxBudgetUSD stops the query once its estimated cost exceeds the given amount in USD.
+	WithMaxBudgetUSD = types.WithMaxBudgetUSD
+	// WithMaxTokens stops the query once its total token usage exceeds the given count.
+	WithMaxTokens = types.WithMaxTokens
+	// WithBudget charges the query to a budget shared with other queries.
+	WithBudget = types.WithBudget
+	// WithRetry retries the query according to a RetryPolicy when it fails for a transient reason.
+	WithRetry = types.WithRetry
+	// WithMaxThinkingTokens limits the tokens Claude can use for internal reasoning.
+	WithMaxThinkingTokens = types.WithMaxThinkingTokens
+	// WithAddDirs adds directories that Claude is allowed to access besides the working directory.
+	WithAddDirs = types.WithAddDirs
+	// WithSettings sets a settings file path or inline JSON settings string.
+	WithSettings = types.WithSettings
+	// WithSettingSources selects which settings files the CLI loads.
+	WithSettingSources = types.WithSettingSources
+	// WithAgent adds or replaces the custom subagent with the given name.
+	WithAgent = types.WithAgent
+	// WithResume resumes the conversation with the given session ID.
+	WithResume = types.WithResume
+	// WithContinueConversation continues the most recent conversation.
+	WithContinueConversation = types.WithContinueConversation
+	// WithIncludePartialMessages streams StreamEvent messages while responses are generated.
+	WithIncludePartialMessages = types.WithIncludePartialMessages
+)
@@ -0,0 +1,151 @@
+package claudecode
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNewClientFunctionalOptions(t *testing.T) {
+	client := NewClient(
+		WithCLIPath("/opt/claude/cli.js"),
+		WithNodePath("/opt/node/bin/node"),
+		WithCWD("/srv/repo"),
+		WithPromptDelivery(PromptDeliveryStdin),
+		WithModel("sonnet"),
+		WithTools("Read", "Grep"),
+		WithPermissionMode(PermissionModeAcceptEdits),
+		WithMCPServer("github", McpStdioServer{Command: "mcp-github"}),
+	)
+
+	if client.cliPath != "/opt/claude/cli.js" {
+		t.Errorf("Client.cliPath = %q, want %q", client.cliPath, "/opt/claude/cli.js")
+	}
+	if client.nodePath != "/opt/node/bin/node" {
+		t.Errorf("Client.nodePath = %q, want %q", client.nodePath, "/opt/node/bin/node")
+	}
+	if client.cwd != "/srv/repo" {
+		t.Errorf("Client.cwd = %q, want %q", client.cwd, "/srv/repo")
+	}
+	if client.promptDelivery != PromptDeliveryStdin {
+		t.Errorf("Client.promptDelivery = %q, want %q", client.promptDelivery, PromptDeliveryStdin)
+	}
+	if client.defaults == nil {
+		t.Fatal("Client.defaults = nil, want query defaults")
+	}
+	if client.defaults.Model != "sonnet" {
+		t.Errorf("defaults.Model = %q, want %q", client.defaults.Model, "sonnet")
+	}
+	if !slices.Equal(client.defaults.AllowedTools, []string{"Read", "Grep"}) {
+		t.Errorf("defaults.AllowedTools = %v, want [Read Grep]", client.defaults.AllowedTools)
+	}
+	if client.defaults.PermissionMode != PermissionModeAcceptEdits {
+		t.Errorf("defaults.PermissionMode = %q, want %q", client.defaults.PermissionMode, PermissionModeAcceptEdits)
+	}
+	if _, ok := client.defaults.McpServers["github"]; !ok {
+		t.Errorf("defaults.McpServers = %v, want github server", client.defaults.McpServers)
+	}
+}
+
+func TestNewClientMixedOptions(t *testing.T) {
+	// A ClientOptions struct can be combined with functional options; later options win
+	client := NewClient(
+		&ClientOptions{CLIPath: "/usr/bin/claude", CWD: "/tmp"},
+		WithCWD("/srv/repo"),
+		nil,
+	)
+	if client.cliPath != "/usr/bin/claude" {
+		t.Errorf("Client.cliPath = %q, want %q", client.cliPath, "/usr/bin/claude")
+	}
+	if client.cwd != "/srv/repo" {
+		t.Errorf("Client.cwd = %q, want %q", client.cwd, "/srv/repo")
+	}
+}
+
+func TestQueryOptionsMerge(t *testing.T) {
+	defaults := NewQueryOptions(
+		WithModel("sonnet"),
+		WithTools("Read", "Grep"),
+		WithSystemPrompt("You are a reviewer."),
+		WithMCPServer("github", McpStdioServer{Command: "mcp-github"}),
+		WithMaxTurns(10),
+	)
+	override := NewQueryOptions(
+		WithModel("opus"),
+		WithTools("Grep", "Bash"),
+		WithMCPServer("jira", McpStdioServer{Command: "mcp-jira"}),
+	)
+
+	merged := defaults.Merge(override)
+
+	if merged.Model != "opus" {
+		t.Errorf("merged.Model = %q, want override %q", merged.Model, "opus")
+	}
+	if merged.SystemPrompt != "You are a reviewer." {
+		t.Errorf("merged.SystemPrompt = %q, want default to be kept", merged.SystemPrompt)
+	}
+	if merged.MaxTurns != 10 {
+		t.Errorf("merged.MaxTurns = %d, want default %d", merged.MaxTurns, 10)
+	}
+	if want := []string{"Read", "Grep", "Bash"}; !slices.Equal(merged.AllowedTools, want) {
+		t.Errorf("merged.AllowedTools = %v, want %v", merged.AllowedTools, want)
+	}
+	if len(merged.McpServers) != 2 {
+		t.Errorf("merged.McpServers = %v, want github and jira", merged.McpServers)
+	}
+
+	// Merging must not modify either input
+	if len(defaults.AllowedTools) != 2 || len(defaults.McpServers) != 1 || defaults.Model != "sonnet" {
+		t.Errorf("Merge() modified the defaults: %+v", defaults)
+	}
+	if len(override.AllowedTools) != 2 || len(override.McpServers) != 1 {
+		t.Errorf("Merge() modified the override: %+v", override)
+	}
+
+	var nilOptions *QueryOptions
+	if got := nilOptions.Merge(nil); got == nil {
+		t.Error("(*QueryOptions)(nil).Merge(nil) = nil, want empty options")
+	}
+}
+
+func TestQueryOptionsMergeOverrides(t *testing.T) {
+	defaults := NewQueryOptions(
+		WithTools("Read", "Bash"),
+		WithDisallowedTools("WebFetch"),
+		WithContinueConversation(),
+		WithIncludePartialMessages(),
+	)
+
+	merged := defaults.Merge(&QueryOptions{
+		Resume:          "session-1",
+		AllowedTools:    []string{"WebFetch"},
+		DisallowedTools: []string{"Bash"},
+	})
+	if merged.ContinueConversation {
+		t.Error("merged.ContinueConversation is not cleared by Resume")
+	}
+	if want := []string{"Read", "WebFetch"}; !slices.Equal(merged.AllowedTools, want) {
+		t.Errorf("merged.AllowedTools = %v, want %v", merged.AllowedTools, want)
+	}
+	if want := []string{"Bash"}; !slices.Equal(merged.DisallowedTools, want) {
+		t.Errorf("merged.DisallowedTools = %v, want %v", merged.DisallowedTools, want)
+	}
+	if !merged.IncludePartialMessages {
+		t.Error("merged.IncludePartialMessages is not inherited")
+	}
+	if err := merged.Validate(); err != nil {
+		t.Errorf("merged.Validate() = %v", err)
+	}
+
+	resumed := NewQueryOptions(WithResume("session-1")).Merge(NewQueryOptions(WithContinueConversation()))
+	if resumed.Resume != "" || !resumed.ContinueConversation {
+		t.Errorf("merged Resume = %q, ContinueConversation = %v; want continue to replace resume", resumed.Resume, resumed.ContinueConversation)
+	}
+
+	// Flags are inherited
+	if merged := defaults.Merge(&QueryOptions{}); !merged.ContinueConversation {
+		t.Error("merged.ContinueConversation is not inherited")
+	}
+	if !defaults.ContinueConversation || len(defaults.AllowedTools) != 2 {
+		t.Errorf("Merge() modified the defaults: %+v", defaults)
+	}
+}
@@ -0,0 +1,97 @@
+package claudecode
+
+import (
+	"context"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/pool"
+)
+
+// Re-export pool statistics from internal package.
+type (
+	// PoolStats is a snapshot of a Pool's activity, including queue depth and wait times.
+	PoolStats = pool.Stats
+)
+
+// PoolOptions configures a Pool. Zero values mean no limit.
+type PoolOptions struct {
+	// MaxConcurrent is the maximum number of CLI processes running at once.
+	MaxConcurrent int
+	// MaxPerKey is the maximum number of CLI processes running at once for the same PoolRequest.Key.
+	MaxPerKey int
+	// KeyLimits overrides MaxPerKey for individual keys.
+	KeyLimits map[string]int
+	// MaxQueued is the maximum number of queries waiting for a slot. Further queries fail
+	// immediately with ErrPoolQueueFull.
+	MaxQueued int
+}
+
+// PoolRequest describes how a query is scheduled by a Pool.
+type PoolRequest struct {
+	// Key groups queries that share a per-key limit, such as the repository they work on.
+	Key string
+	// Priority orders waiting queries. Higher priorities run first; equal priorities run in FIFO order.
+	Priority int
+}
+
+// Pool wraps a Client and limits how many queries, and thus CLI processes, run concurrently.
+// Queries over the limit wait in a queue until a slot frees up or their context ends.
+// It is safe for concurrent use.
+type Pool struct {
+	client  *Client
+	limiter *pool.Limiter
+}
+
+// NewPool creates a Pool running queries with client. options may be nil.
+func NewPool(client *Client, options *PoolOptions) *Pool {
+	var config pool.Config
+	if options != nil {
+		config = pool.Config{
+			MaxConcurrent: options.MaxConcurrent,
+			MaxPerKey:     options.MaxPerKey,
+			KeyLimits:     options.KeyLimits,
+			MaxQueued:     options.MaxQueued,
+		}
+	}
+	return &Pool{client: client, limiter: pool.NewLimiter(config)}
+}
+
+// Query waits for a slot and then runs the query like Client.Query.
+// The slot is held until the returned channel is closed.
+func (p *Pool) Query(ctx context.Context, request PoolRequest, prompt string, options *QueryOptions) (<-chan Message, error) {
+	return p.QueryPrompt(ctx, request, NewPrompt().Text(prompt), options)
+}
+
+// QueryPrompt waits for a slot and then runs the query like Client.QueryPrompt.
+// The slot is held until the returned channel is closed.
+func (p *Pool) QueryPrompt(ctx context.Context, request PoolRequest, prompt *Prompt, options *QueryOptions) (<-chan Message, error) {
+	release, err := p.limiter.Acquire(ctx, request.Key, request.Priority)
+	if err != nil {
+		return nil, err
+	}
+
+	messageCh, err := p.client.QueryPrompt(ctx, prompt, options)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	wrappedCh := make(chan Message, cap(messageCh))
+	go func() {
+		defer close(wrappedCh)
+		// Keep the slot until the client has shut the CLI process down
+		defer release()
+		for message := range messageCh {
+			select {
+			case wrappedCh <- message:
+			case <-ctx.Done():
+				// Drain the remaining messages; the client stops the query on cancellation
+			}
+		}
+	}()
+	return wrappedCh, nil
+}
+
+// Stats returns a snapshot of the pool's activity.
+func (p *Pool) Stats() PoolStats {
+	return p.limiter.Stats()
+}
@@ -0,0 +1,62 @@
+package claudecode
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	// The fake CLI records how many instances run at once
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fake-claude")
+	script := `#!/bin/sh
+if [ "$1" = "--version" ]; then echo "2.0.0 (Claude Code)"; exit 0; fi
+mkdir "` + dir + `/running" 2>/dev/null || echo overlap >> "` + dir + `/overlaps"
+sleep 0.05
+rmdir "` + dir + `/running"
+echo '{"type":"result","subtype":"success","duration_ms":10,"duration_api_ms":5,"is_error":false,"num_turns":1,"session_id":"s1"}'
+`
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewPool(NewClient(WithCLIPath(path)), &PoolOptions{MaxConcurrent: 1})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			messages, err := p.Query(ctx, PoolRequest{Key: "repo"}, "work", nil)
+			if err != nil {
+				t.Errorf("Query() error = %v", err)
+				return
+			}
+			var results int
+			for message := range messages {
+				if _, ok := message.(*ResultMessage); ok {
+					results++
+				}
+			}
+			if results != 1 {
+				t.Errorf("got %d result messages, want 1", results)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := os.Stat(filepath.Join(dir, "overlaps")); err == nil {
+		t.Error("CLI processes ran concurrently despite MaxConcurrent = 1")
+	}
+	stats := p.Stats()
+	if stats.Acquired != 4 || stats.Active != 0 || stats.Queued != 0 {
+		t.Errorf("Stats() = %+v, want 4 acquired and none active", stats)
+	}
+	if stats.MaxWait <= 0 || stats.AverageWait() <= 0 {
+		t.Errorf("Stats() = %+v, want queued queries to report wait time", stats)
+	}
+}
@@ -0,0 +1,92 @@
+package claudecode
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/ratelimit"
+)
+
+// Re-export rate limiting types from internal package.
+type (
+	// RateLimiter paces the start of queries so that each model stays within its rate limit.
+	// Share one RateLimiter between all clients that use the same API key.
+	RateLimiter = ratelimit.Limiter
+	// RateLimit is the rate limit of a model. Zero values mean no limit.
+	RateLimit = ratelimit.Limit
+	// RateLimiterOptions configures a RateLimiter.
+	RateLimiterOptions = ratelimit.Config
+)
+
+// DefaultRateLimitCooldown is how long a model is paused after a rate limit error that did
+// not say when to retry.
+const DefaultRateLimitCooldown = ratelimit.DefaultCooldown
+
+// NewRateLimiter creates a RateLimiter with the given options.
+var NewRateLimiter = ratelimit.NewLimiter
+
+// rateGate is the rate limit reservation of a running query. A nil *rateGate does nothing.
+type rateGate struct {
+	limiter     *ratelimit.Limiter
+	logger      *slog.Logger
+	model       string
+	reservation *ratelimit.Reservation
+}
+
+// reserve waits until the client's rate limiter lets the query start. It returns a nil
+// *rateGate if the client has no rate limiter.
+func (c *Client) reserve(ctx context.Context, options *QueryOptions) (*rateGate, error) {
+	if c.rateLimiter == nil {
+		return nil, nil
+	}
+	reservation, err := c.rateLimiter.Wait(ctx, options.Model)
+	if err != nil {
+		return nil, err
+	}
+	return &rateGate{limiter: c.rateLimiter, logger: c.logger, model: options.Model, reservation: reservation}, nil
+}
+
+// observe pauses the query's model if the message reports that its rate limit was hit.
+func (g *rateGate) observe(message Message) {
+	if g == nil {
+		return
+	}
+
+	var wait time.Duration
+	var limited bool
+	switch msg := message.(type) {
+	case *SystemMessage:
+		wait, limited = ratelimit.FromEvent(msg.Subtype, msg.Data, time.Now())
+	case *ResultMessage:
+		if msg.IsError && msg.Result != nil {
+			wait, limited = ratelimit.FromText(*msg.Result)
+		}
+	case *ErrorMessage:
+		var processErr *ProcessError
+		if errors.As(msg.Err, &processErr) {
+			wait, limited = ratelimit.FromText(processErr.Stderr)
+		}
+	}
+	if limited {
+		g.logger.Warn("rate limit reached, pausing model", "model", g.model, "retry_after", wait)
+		g.limiter.Pause(g.model, wait)
+	}
+}
+
+// cancel returns the reservation of a query that could not be started.
+func (g *rateGate) cancel() {
+	if g == nil {
+		return
+	}
+	g.reservation.Cancel()
+}
+
+// settle corrects the query's token reservation with the tokens it used.
+func (g *rateGate) settle(tokens int) {
+	if g == nil {
+		return
+	}
+	g.reservation.Settle(tokens)
+}
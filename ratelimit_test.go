@@ -0,0 +1,48 @@
+package claudecode
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClientRateLimiter(t *testing.T) {
+	query := func(client *Client, model string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		messages, err := client.Query(ctx, "work", NewQueryOptions(WithModel(model)))
+		if err != nil {
+			return err
+		}
+		for range messages {
+		}
+		return nil
+	}
+
+	t.Run("requests per minute", func(t *testing.T) {
+		limiter := NewRateLimiter(RateLimiterOptions{Models: map[string]RateLimit{"opus": {RequestsPerMinute: 1}}})
+		client := NewClient(WithCLIPath(writeFlakyCLI(t, t.TempDir(), 0, "")), WithRateLimiter(limiter))
+
+		if err := query(client, "opus"); err != nil {
+			t.Fatalf("first Query() error = %v", err)
+		}
+		if err := query(client, "opus"); err == nil {
+			t.Error("second Query() within a minute should wait for the rate limit")
+		}
+		if err := query(client, "sonnet"); err != nil {
+			t.Errorf("Query() for another model error = %v", err)
+		}
+	})
+
+	t.Run("rate limit error", func(t *testing.T) {
+		limiter := NewRateLimiter(RateLimiterOptions{})
+		client := NewClient(WithCLIPath(writeFlakyCLI(t, t.TempDir(), 1, "API Error: 429 rate_limit_error, retry-after: 60")), WithRateLimiter(limiter))
+
+		if err := query(client, "opus"); err != nil {
+			t.Fatalf("first Query() error = %v", err)
+		}
+		if err := query(client, "opus"); err == nil {
+			t.Error("Query() after a rate limit error should wait for the retry-after")
+		}
+	})
+}
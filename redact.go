@@ -0,0 +1,104 @@
+package claudecode
+
+import (
+	"github.com/musaprg/claude-code-sdk-go/internal/redact"
+)
+
+// Re-export redaction types from internal package.
+type (
+	// Redactor replaces secrets such as API keys and tokens in text. A nil *Redactor leaves text unchanged.
+	Redactor = redact.Redactor
+	// RedactorOptions configures a Redactor.
+	RedactorOptions = redact.Options
+)
+
+// DefaultRedactionReplacement replaces secrets redacted by a Redactor.
+const DefaultRedactionReplacement = redact.DefaultReplacement
+
+// DefaultRedactionPatterns match common credential formats. RedactorOptions.Patterns are
+// used in addition to them unless RedactorOptions.DisableDefaultPatterns is set.
+var DefaultRedactionPatterns = redact.DefaultPatterns
+
+// NewRedactor creates a Redactor with the given options, which may be nil.
+// The Redactor of a client scrubs its log records and process errors; use RedactMessage
+// to scrub messages before recording them, e.g. in transcripts.
+var NewRedactor = redact.New
+
+// RedactMessage returns a copy of message with every secret matched by redactor replaced in
+// its text, tool inputs and results, metadata, and error. The error of an *ErrorMessage keeps
+// wrapping the original error, so errors.As still finds it. A nil redactor uses the default patterns.
+func RedactMessage(redactor *Redactor, message Message) Message {
+	if redactor == nil {
+		redactor = redact.New(nil)
+	}
+
+	switch msg := message.(type) {
+	case *UserMessage:
+		return &UserMessage{Content: redactor.String(msg.Content), ContentBlocks: redactBlocks(redactor, msg.ContentBlocks)}
+	case *AssistantMessage:
+		redacted := *msg
+		redacted.Content = redactBlocks(redactor, msg.Content)
+		return &redacted
+	case *SystemMessage:
+		return &SystemMessage{Subtype: msg.Subtype, Data: redactMap(redactor, msg.Data)}
+	case *ResultMessage:
+		redacted := *msg
+		if msg.Result != nil {
+			result := redactor.String(*msg.Result)
+			redacted.Result = &result
+		}
+		return &redacted
+	case *StreamEvent:
+		redacted := *msg
+		redacted.Event = redactMap(redactor, msg.Event)
+		return &redacted
+	case *ErrorMessage:
+		return &ErrorMessage{Err: &redactedError{text: redactor.String(msg.Err.Error()), err: msg.Err}}
+	default:
+		return message
+	}
+}
+
+// redactBlocks returns copies of content blocks with their text, tool inputs, and tool results redacted.
+// Images and documents are returned as they are.
+func redactBlocks(redactor *Redactor, blocks []ContentBlock) []ContentBlock {
+	if blocks == nil {
+		return nil
+	}
+	redacted := make([]ContentBlock, len(blocks))
+	for i, block := range blocks {
+		switch b := block.(type) {
+		case *TextBlock:
+			redacted[i] = &TextBlock{Text: redactor.String(b.Text)}
+		case *ToolUseBlock:
+			redacted[i] = &ToolUseBlock{ID: b.ID, Name: b.Name, Input: redactMap(redactor, b.Input)}
+		case *ToolResultBlock:
+			redacted[i] = &ToolResultBlock{ToolUseID: b.ToolUseID, Content: redactor.Value(b.Content), IsError: b.IsError}
+		default:
+			redacted[i] = block
+		}
+	}
+	return redacted
+}
+
+// redactMap returns a redacted copy of a decoded JSON object, keeping nil maps nil.
+func redactMap(redactor *Redactor, value map[string]any) map[string]any {
+	if value == nil {
+		return nil
+	}
+	return redactor.Value(value).(map[string]any)
+}
+
+// redactedError reports the redacted text of an error while still wrapping it.
+type redactedError struct {
+	text string
+	err  error
+}
+
+func (e *redactedError) Error() string {
+	return e.text
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,131 @@
+package claudecode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClientRedaction(t *testing.T) {
+	dir := t.TempDir()
+	cliPath := writeFlakyCLI(t, dir, 1, "request for tenant-blue-key failed with ghp_mcp_token_value")
+
+	var logs bytes.Buffer
+	client := NewClient(
+		WithCLIPath(cliPath),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		WithRedactor(NewRedactor(&RedactorOptions{Secrets: []string{"tenant-blue-key"}})),
+		WithMCPServer("github", McpStdioServer{Command: "github-mcp", Env: map[string]string{"GITHUB_TOKEN": "ghp_mcp_token_value"}}),
+	)
+
+	messageCh, err := client.Query(context.Background(), "hi", nil)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	var processErr *ProcessError
+	for message := range messageCh {
+		if errorMsg, ok := message.(*ErrorMessage); ok {
+			errors.As(errorMsg, &processErr)
+		}
+	}
+	if processErr == nil {
+		t.Fatal("Query() did not report a ProcessError")
+	}
+
+	want := "request for [REDACTED] failed with [REDACTED]"
+	if !strings.Contains(processErr.Stderr, want) || !strings.Contains(processErr.Error(), want) {
+		t.Errorf("ProcessError = %q, stderr %q, want %q", processErr.Error(), processErr.Stderr, want)
+	}
+	for name, text := range map[string]string{"logs": logs.String(), "argv": strings.Join(readArgs(t, dir), " ")} {
+		if strings.Contains(text, "tenant-blue-key") || strings.Contains(text, "ghp_mcp_token_value") {
+			t.Errorf("%s contain a secret:\n%s", name, text)
+		}
+	}
+}
+
+func TestRedactMessage(t *testing.T) {
+	redactor := NewRedactor(&RedactorOptions{Secrets: []string{"s3cret-value"}})
+	result := "the key is s3cret-value"
+	isError := false
+	original := &AssistantMessage{Model: "sonnet", Content: []ContentBlock{
+		NewTextBlock("using s3cret-value"),
+		NewToolUseBlock("t1", "Bash", map[string]any{"command": "export KEY=s3cret-value"}),
+		NewToolResultBlock("t1", []any{map[string]any{"type": "text", "text": "s3cret-value"}}, &isError),
+	}}
+
+	redacted := RedactMessage(redactor, original).(*AssistantMessage)
+	if redacted.Model != "sonnet" {
+		t.Errorf("Model = %q, want it kept", redacted.Model)
+	}
+	if text := redacted.Content[0].(*TextBlock).Text; text != "using [REDACTED]" {
+		t.Errorf("TextBlock.Text = %q", text)
+	}
+	if command := redacted.Content[1].(*ToolUseBlock).Input["command"]; command != "export KEY=[REDACTED]" {
+		t.Errorf("ToolUseBlock.Input[command] = %q", command)
+	}
+	content := redacted.Content[2].(*ToolResultBlock).Content.([]any)
+	if text := content[0].(map[string]any)["text"]; text != "[REDACTED]" {
+		t.Errorf("ToolResultBlock.Content text = %q", text)
+	}
+	if text := original.Content[0].(*TextBlock).Text; text != "using s3cret-value" {
+		t.Error("RedactMessage() modified its input")
+	}
+
+	resultMsg := RedactMessage(redactor, &ResultMessage{Subtype: "success", Result: &result}).(*ResultMessage)
+	if *resultMsg.Result != "the key is [REDACTED]" || result != "the key is s3cret-value" {
+		t.Errorf("ResultMessage.Result = %q", *resultMsg.Result)
+	}
+
+	processErr := NewProcessError("failed with s3cret-value", 1, "s3cret-value", nil)
+	errorMsg := RedactMessage(redactor, NewErrorMessage(processErr)).(*ErrorMessage)
+	if errorMsg.Error() != "failed with [REDACTED]" {
+		t.Errorf("ErrorMessage.Error() = %q", errorMsg.Error())
+	}
+	var target *ProcessError
+	if !errors.As(errorMsg, &target) {
+		t.Error("redacted ErrorMessage does not wrap the original error")
+	}
+}
+
+func TestClientRedactsLogsOnce(t *testing.T) {
+	dir := t.TempDir()
+	cliPath := writeFlakyCLI(t, dir, 1, "request for tenant-blue-key failed")
+	cwd := filepath.Join(dir, "tenant-blue-key")
+	if err := os.Mkdir(cwd, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// A replacement that contains the secret shows how often a record was scrubbed
+	var logs bytes.Buffer
+	client := NewClient(
+		WithCLIPath(cliPath),
+		WithCWD(cwd),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		WithRedactor(NewRedactor(&RedactorOptions{
+			Secrets:                []string{"tenant-blue-key"},
+			Replacement:            "<tenant-blue-key>",
+			DisableDefaultPatterns: true,
+		})),
+	)
+
+	messageCh, err := client.Query(context.Background(), "hi", nil)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	for range messageCh {
+	}
+
+	for _, want := range []string{"/<tenant-blue-key>", "request for <tenant-blue-key> failed"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("logs do not contain %q:\n%s", want, logs.String())
+		}
+	}
+	if strings.Contains(logs.String(), "<<tenant-blue-key>>") {
+		t.Errorf("logs were scrubbed more than once:\n%s", logs.String())
+	}
+}
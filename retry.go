@@ -0,0 +1,168 @@
+package claudecode
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/budget"
+	"github.com/musaprg/claude-code-sdk-go/internal/types"
+)
+
+// Re-export retry types from internal package.
+type (
+	// RetryPolicy retries queries that fail for transient reasons, such as API overload,
+	// rate limiting, or network errors. Zero values are replaced by the defaults.
+	RetryPolicy = types.RetryPolicy
+	// QueryFailure describes how an attempt of a query failed.
+	QueryFailure = types.QueryFailure
+)
+
+// Default retry policy settings
+const (
+	DefaultRetryMaxAttempts    = types.DefaultRetryMaxAttempts
+	DefaultRetryInitialBackoff = types.DefaultRetryInitialBackoff
+	DefaultRetryMaxBackoff     = types.DefaultRetryMaxBackoff
+	DefaultRetryMultiplier     = types.DefaultRetryMultiplier
+	DefaultRetryResumePrompt   = types.DefaultRetryResumePrompt
+)
+
+// IsRetryable is the default classification of failures used by RetryPolicy.
+// Failures reported as error_during_execution, and failures whose result text or CLI error
+// output indicates overload, rate limiting, or a network error, are retryable.
+var IsRetryable = types.IsRetryable
+
+// retry forwards the messages of the first attempt of a query and retries the query while
+// its attempts fail for a retryable reason. A failed attempt's ResultMessage and
+// process ErrorMessage are withheld unless no further attempt is made. Retries resume the
+// session of the failed attempt if the CLI reported one, and start over otherwise.
+func (c *Client) retry(ctx context.Context, prompt *Prompt, options *QueryOptions, messageCh <-chan Message) <-chan Message {
+	policy := options.Retry.WithDefaults()
+
+	wrappedCh := make(chan Message, 10)
+	go func() {
+		defer close(wrappedCh)
+
+		send := func(message Message) bool {
+			select {
+			case wrappedCh <- message:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		// meter tracks the spend of all attempts so that retries share the query's limits
+		meter := budget.NewMeter()
+		var sessionID string
+
+		for attempt := 1; ; attempt++ {
+			failure := &QueryFailure{Attempt: attempt}
+			var withheld []Message
+
+			for message := range messageCh {
+				switch msg := message.(type) {
+				case *SystemMessage:
+					if id, ok := msg.Data["session_id"].(string); ok && id != "" {
+						sessionID = id
+					}
+				case *AssistantMessage:
+					if msg.Usage != nil {
+						meter.Record(msg.ID, msg.Model, *msg.Usage)
+					}
+				case *ResultMessage:
+					if msg.SessionID != "" {
+						sessionID = msg.SessionID
+					}
+					if msg.IsError {
+						failure.Result = msg
+						withheld = append(withheld, msg)
+						continue
+					}
+				case *ErrorMessage:
+					var processErr *ProcessError
+					if errors.As(msg.Err, &processErr) {
+						failure.Err = msg.Err
+						withheld = append(withheld, msg)
+						continue
+					}
+				}
+
+				// The failure was not the end of the attempt after all
+				for _, held := range withheld {
+					if !send(held) {
+						return
+					}
+				}
+				withheld = nil
+				if !send(message) {
+					return
+				}
+			}
+
+			if len(withheld) == 0 || ctx.Err() != nil || attempt >= policy.MaxAttempts || !policy.Retryable(failure) {
+				for _, held := range withheld {
+					if !send(held) {
+						return
+					}
+				}
+				return
+			}
+
+			backoff := policy.Backoff(attempt)
+			c.logger.Warn("retrying failed query", "attempt", attempt, "backoff", backoff, "resume", sessionID != "", "error", failureReason(failure))
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			next, err := retryOptions(options, meter)
+			if err != nil {
+				send(NewErrorMessage(err))
+				return
+			}
+			nextPrompt := prompt
+			if sessionID != "" {
+				next.Resume = sessionID
+				next.ContinueConversation = false
+				nextPrompt = NewPrompt().Text(policy.ResumePrompt)
+			}
+
+			if messageCh, err = c.start(ctx, nextPrompt, next); err != nil {
+				send(NewErrorMessage(err))
+				return
+			}
+		}
+	}()
+
+	return wrappedCh
+}
+
+// failureReason describes a failed attempt for logging.
+func failureReason(failure *QueryFailure) string {
+	if failure.Err != nil {
+		return failure.Err.Error()
+	}
+	return failure.Result.Subtype
+}
+
+// retryOptions returns the options of the next attempt of a query, with its per-query limits
+// reduced by what the previous attempts spent. It returns a *BudgetExceededError if nothing is left.
+func retryOptions(options *QueryOptions, meter *budget.Meter) (*QueryOptions, error) {
+	next := *options
+	costUSD, tokens := meter.CostUSD(), meter.Usage().TotalTokens()
+	if options.MaxBudgetUSD > 0 {
+		if next.MaxBudgetUSD -= costUSD; next.MaxBudgetUSD <= 0 {
+			return nil, NewBudgetExceededError(options.MaxBudgetUSD, options.MaxTokens, costUSD, tokens)
+		}
+	}
+	if options.MaxTokens > 0 {
+		if next.MaxTokens -= tokens; next.MaxTokens <= 0 {
+			return nil, NewBudgetExceededError(options.MaxBudgetUSD, options.MaxTokens, costUSD, tokens)
+		}
+	}
+	return &next, nil
+}
@@ -0,0 +1,169 @@
+package claudecode
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFlakyCLI writes a fake CLI that fails with stderr on its first failures runs and
+// succeeds afterwards. The arguments of each run are appended to dir/args.
+func writeFlakyCLI(t *testing.T, dir string, failures int, stderr string) string {
+	t.Helper()
+	path := filepath.Join(dir, "fake-claude")
+	script := `#!/bin/sh
+if [ "$1" = "--version" ]; then echo "2.0.0 (Claude Code)"; exit 0; fi
+echo "$*" >> "` + dir + `/args"
+runs=$(wc -l < "` + dir + `/args")
+echo '{"type":"system","subtype":"init","session_id":"s1"}'
+if [ "$runs" -le ` + strconv.Itoa(failures) + ` ]; then
+	echo '` + stderr + `' >&2
+	exit 1
+fi
+echo '{"type":"result","subtype":"success","duration_ms":10,"duration_api_ms":5,"is_error":false,"num_turns":1,"session_id":"s1"}'
+`
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readArgs(t *testing.T, dir string) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestClientQueryRetry(t *testing.T) {
+	dir := t.TempDir()
+	client := NewClient(WithCLIPath(writeFlakyCLI(t, dir, 2, "API Error: 529 Overloaded")))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	messages, err := client.Query(ctx, "work", NewQueryOptions(WithRetry(&RetryPolicy{InitialBackoff: time.Millisecond})))
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+
+	var received []Message
+	for message := range messages {
+		received = append(received, message)
+		if errorMsg, ok := message.(*ErrorMessage); ok {
+			t.Errorf("unexpected ErrorMessage: %v", errorMsg)
+		}
+	}
+	if len(received) != 4 {
+		t.Fatalf("got %d messages, want three init messages and a result", len(received))
+	}
+	if _, ok := received[3].(*ResultMessage); !ok {
+		t.Errorf("last message = %T, want *ResultMessage", received[3])
+	}
+
+	args := readArgs(t, dir)
+	if len(args) != 3 {
+		t.Fatalf("CLI ran %d times, want 3", len(args))
+	}
+	if strings.Contains(args[0], "--resume") {
+		t.Errorf("first attempt args = %q, want no --resume", args[0])
+	}
+	for _, retryArgs := range args[1:] {
+		if !strings.Contains(retryArgs, "--resume s1") || !strings.Contains(retryArgs, DefaultRetryResumePrompt) {
+			t.Errorf("retry args = %q, want the session resumed with the resume prompt", retryArgs)
+		}
+	}
+}
+
+func TestClientQueryRetryGivesUp(t *testing.T) {
+	tests := []struct {
+		name     string
+		stderr   string
+		attempts int
+	}{
+		{name: "fatal failure", stderr: "Invalid API key", attempts: 1},
+		{name: "attempts exhausted", stderr: `API Error: 429 {"type":"error","error":{"type":"rate_limit_error","message":"rate limit exceeded"}}`, attempts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			client := NewClient(WithCLIPath(writeFlakyCLI(t, dir, 9, tt.stderr)))
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			policy := &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+			messages, err := client.Query(ctx, "work", NewQueryOptions(WithRetry(policy)))
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+
+			var last Message
+			for message := range messages {
+				last = message
+			}
+			var processErr *ProcessError
+			errorMsg, ok := last.(*ErrorMessage)
+			if !ok || !errors.As(errorMsg, &processErr) {
+				t.Fatalf("last message = %v, want an ErrorMessage wrapping a *ProcessError", last)
+			}
+			if processErr.ExitCode != 1 || !strings.Contains(processErr.Stderr, tt.stderr) {
+				t.Errorf("ProcessError = (%d, %q), want exit code 1 and the CLI's stderr", processErr.ExitCode, processErr.Stderr)
+			}
+			if got := len(readArgs(t, dir)); got != tt.attempts {
+				t.Errorf("CLI ran %d times, want %d", got, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	text := func(s string) *string { return &s }
+	tests := []struct {
+		name    string
+		failure *QueryFailure
+		want    bool
+	}{
+		{name: "overloaded", failure: &QueryFailure{Err: NewProcessError("failed", 1, "API Error: 529 overloaded_error", nil)}, want: true},
+		{name: "network", failure: &QueryFailure{Err: NewProcessError("failed", 1, "Error: socket hang up", nil)}, want: true},
+		{name: "fatal stderr", failure: &QueryFailure{Err: NewProcessError("failed", 1, "Invalid API key", nil)}, want: false},
+		{name: "no error", failure: &QueryFailure{}, want: false},
+		{name: "error during execution", failure: &QueryFailure{Result: &ResultMessage{Subtype: "error_during_execution", IsError: true}}, want: true},
+		{name: "max turns", failure: &QueryFailure{Result: &ResultMessage{Subtype: "error_max_turns", IsError: true}}, want: false},
+		{name: "transient result", failure: &QueryFailure{Result: &ResultMessage{Subtype: "success", IsError: true, Result: text("API Error: Request timed out")}}, want: true},
+		{name: "failed result", failure: &QueryFailure{Result: &ResultMessage{Subtype: "success", IsError: true, Result: text("Prompt is too long")}}, want: false},
+		{name: "rate limit error type", failure: &QueryFailure{Err: NewProcessError("failed", 1,
+			`API Error: 429 {"type":"error","error":{"type":"rate_limit_error","message":"Number of request tokens has exceeded your per-minute rate limit"}}`, nil)}, want: true},
+		{name: "invalid request error type", failure: &QueryFailure{Err: NewProcessError("failed", 1,
+			`API Error: 400 {"type":"error","error":{"type":"invalid_request_error","message":"Request timed out waiting for rate limit"}}`, nil)}, want: false},
+		{name: "connection error", failure: &QueryFailure{Err: NewProcessError("failed", 1, "API Error: Connection error.", nil)}, want: true},
+		{name: "node network error", failure: &QueryFailure{Err: NewProcessError("failed", 1, "Error: read ECONNRESET\n    at TLSWrap.onStreamRead", nil)}, want: true},
+		{name: "timeout in file path", failure: &QueryFailure{Err: NewProcessError("failed", 1, "Error: ENOENT: no such file or directory, open '/src/timeout/network.go'", nil)}, want: false},
+		{name: "timeout in test output", failure: &QueryFailure{Result: &ResultMessage{Subtype: "success", IsError: true, Result: text("--- FAIL: TestRequestTimeout (0.00s)\nrate limit test failed")}}, want: false},
+		{name: "line number 429", failure: &QueryFailure{Err: NewProcessError("failed", 1, "SyntaxError: /src/app.js:429: Unexpected token", nil)}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.failure); got != tt.want {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}.WithDefaults()
+	for attempt, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		got := policy.Backoff(attempt + 1)
+		if got < want/2 || got > want {
+			t.Errorf("Backoff(%d) = %v, want between %v and %v", attempt+1, got, want/2, want)
+		}
+	}
+}
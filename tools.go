@@ -0,0 +1,81 @@
+package claudecode
+
+import "github.com/musaprg/claude-code-sdk-go/internal/types"
+
+// Re-export tool names, permission rule builders, and typed tool inputs from internal package.
+// Use them to build AllowedTools/DisallowedTools lists and to decode ToolUseBlock.Input safely.
+type (
+	// BashInput is the input of the Bash tool.
+	BashInput = types.BashInput
+	// ReadInput is the input of the Read tool.
+	ReadInput = types.ReadInput
+	// WriteInput is the input of the Write tool.
+	WriteInput = types.WriteInput
+	// EditInput is the input of the Edit tool.
+	EditInput = types.EditInput
+	// EditOperation is a single replacement within a MultiEdit tool call.
+	EditOperation = types.EditOperation
+	// MultiEditInput is the input of the MultiEdit tool.
+	MultiEditInput = types.MultiEditInput
+	// GlobInput is the input of the Glob tool.
+	GlobInput = types.GlobInput
+	// GrepInput is the input of the Grep tool.
+	GrepInput = types.GrepInput
+	// WebFetchInput is the input of the WebFetch tool.
+	WebFetchInput = types.WebFetchInput
+	// WebSearchInput is the input of the WebSearch tool.
+	WebSearchInput = types.WebSearchInput
+	// TaskInput is the input of the Task tool.
+	TaskInput = types.TaskInput
+	// TodoStatus is the status of an item in a TodoWrite list.
+	TodoStatus = types.TodoStatus
+	// TodoItem is a single entry of a TodoWrite list.
+	TodoItem = types.TodoItem
+	// TodoWriteInput is the input of the TodoWrite tool.
+	TodoWriteInput = types.TodoWriteInput
+	// NotebookEditInput is the input of the NotebookEdit tool.
+	NotebookEditInput = types.NotebookEditInput
+)
+
+// Names of the built-in Claude Code tools.
+const (
+	ToolBash         = types.ToolBash
+	ToolBashOutput   = types.ToolBashOutput
+	ToolKillShell    = types.ToolKillShell
+	ToolRead         = types.ToolRead
+	ToolWrite        = types.ToolWrite
+	ToolEdit         = types.ToolEdit
+	ToolMultiEdit    = types.ToolMultiEdit
+	ToolGlob         = types.ToolGlob
+	ToolGrep         = types.ToolGrep
+	ToolLS           = types.ToolLS
+	ToolWebFetch     = types.ToolWebFetch
+	ToolWebSearch    = types.ToolWebSearch
+	ToolTask         = types.ToolTask
+	ToolTodoWrite    = types.ToolTodoWrite
+	ToolNotebookEdit = types.ToolNotebookEdit
+	ToolExitPlanMode = types.ToolExitPlanMode
+)
+
+// Statuses of TodoWrite list items.
+const (
+	// TodoStatusPending marks a todo that has not been started.
+	TodoStatusPending = types.TodoStatusPending
+	// TodoStatusInProgress marks the todo currently being worked on.
+	TodoStatusInProgress = types.TodoStatusInProgress
+	// TodoStatusCompleted marks a finished todo.
+	TodoStatusCompleted = types.TodoStatusCompleted
+)
+
+var (
+	// ToolRule builds a scoped permission rule such as "Bash(git diff:*)".
+	ToolRule = types.ToolRule
+	// BashCommandRule builds a rule matching exactly the given Bash command.
+	BashCommandRule = types.BashCommandRule
+	// BashPrefixRule builds a rule matching every Bash command starting with the given prefix.
+	BashPrefixRule = types.BashPrefixRule
+	// MCPToolName returns the name under which an MCP server's tool is exposed.
+	MCPToolName = types.MCPToolName
+	// IsMCPTool reports whether a tool name refers to a tool provided by an MCP server.
+	IsMCPTool = types.IsMCPTool
+)
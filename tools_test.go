@@ -0,0 +1,141 @@
+package claudecode
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestToolRules(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "bare tool", got: ToolRule(ToolRead, ""), want: "Read"},
+		{name: "scoped tool", got: ToolRule(ToolEdit, "src/**"), want: "Edit(src/**)"},
+		{name: "bash command", got: BashCommandRule("npm test"), want: "Bash(npm test)"},
+		{name: "bash prefix", got: BashPrefixRule("git diff"), want: "Bash(git diff:*)"},
+		{name: "mcp tool", got: MCPToolName("github", "create_issue"), want: "mcp__github__create_issue"},
+		{name: "mcp server", got: MCPToolName("github", ""), want: "mcp__github"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+
+	if !IsMCPTool("mcp__github__create_issue") || IsMCPTool(ToolBash) {
+		t.Error("IsMCPTool() misclassifies tool names")
+	}
+}
+
+func TestToolUseBlockDecodeInput(t *testing.T) {
+	block := NewToolUseBlock("tool-1", ToolBash, map[string]any{
+		"command": "go test ./...",
+		"timeout": float64(60000),
+	})
+
+	var input BashInput
+	if err := block.DecodeInput(&input); err != nil {
+		t.Fatalf("ToolUseBlock.DecodeInput() error = %v", err)
+	}
+	if input.Command != "go test ./..." {
+		t.Errorf("BashInput.Command = %q, want %q", input.Command, "go test ./...")
+	}
+	if input.Timeout != 60000 {
+		t.Errorf("BashInput.Timeout = %d, want %d", input.Timeout, 60000)
+	}
+
+	bad := NewToolUseBlock("tool-2", ToolRead, map[string]any{"file_path": 42})
+	var readInput ReadInput
+	err := bad.DecodeInput(&readInput)
+	var parseErr *MessageParseError
+	if !errors.As(err, &parseErr) {
+		t.Errorf("ToolUseBlock.DecodeInput() error = %v, want *MessageParseError", err)
+	}
+}
+
+func TestToolUseBlockTypedInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		block *ToolUseBlock
+		check func(t *testing.T, input any)
+	}{
+		{
+			name: "MultiEdit",
+			block: NewToolUseBlock("1", ToolMultiEdit, map[string]any{
+				"file_path": "/repo/main.go",
+				"edits": []any{
+					map[string]any{"old_string": "a", "new_string": "b"},
+					map[string]any{"old_string": "c", "new_string": "d", "replace_all": true},
+				},
+			}),
+			check: func(t *testing.T, input any) {
+				multiEdit, ok := input.(*MultiEditInput)
+				if !ok {
+					t.Fatalf("TypedInput() = %T, want *MultiEditInput", input)
+				}
+				if multiEdit.FilePath != "/repo/main.go" || len(multiEdit.Edits) != 2 || !multiEdit.Edits[1].ReplaceAll {
+					t.Errorf("MultiEditInput = %+v, want two edits on /repo/main.go", multiEdit)
+				}
+			},
+		},
+		{
+			name: "TodoWrite",
+			block: NewToolUseBlock("2", ToolTodoWrite, map[string]any{
+				"todos": []any{
+					map[string]any{"content": "Run tests", "status": "in_progress", "activeForm": "Running tests"},
+				},
+			}),
+			check: func(t *testing.T, input any) {
+				todoWrite, ok := input.(*TodoWriteInput)
+				if !ok {
+					t.Fatalf("TypedInput() = %T, want *TodoWriteInput", input)
+				}
+				if len(todoWrite.Todos) != 1 || todoWrite.Todos[0].Status != TodoStatusInProgress {
+					t.Errorf("TodoWriteInput = %+v, want one in-progress todo", todoWrite)
+				}
+			},
+		},
+		{
+			name:  "MCP tool",
+			block: NewToolUseBlock("3", MCPToolName("github", "get_issue"), map[string]any{"number": float64(7)}),
+			check: func(t *testing.T, input any) {
+				raw, ok := input.(map[string]any)
+				if !ok || raw["number"] != float64(7) {
+					t.Errorf("TypedInput() = %#v, want the raw input map", input)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := tt.block.TypedInput()
+			if err != nil {
+				t.Fatalf("ToolUseBlock.TypedInput() error = %v", err)
+			}
+			tt.check(t, input)
+		})
+	}
+}
+
+func TestToolUseBlockFilePath(t *testing.T) {
+	edit := NewToolUseBlock("1", ToolEdit, map[string]any{"file_path": "/repo/a.go"})
+	if path, ok := edit.FilePath(); !ok || path != "/repo/a.go" {
+		t.Errorf("Edit FilePath() = %q, %v; want /repo/a.go, true", path, ok)
+	}
+
+	notebook := NewToolUseBlock("2", ToolNotebookEdit, map[string]any{"notebook_path": "/repo/n.ipynb"})
+	if path, ok := notebook.FilePath(); !ok || path != "/repo/n.ipynb" {
+		t.Errorf("NotebookEdit FilePath() = %q, %v; want /repo/n.ipynb, true", path, ok)
+	}
+
+	bash := NewToolUseBlock("3", ToolBash, map[string]any{"command": "ls"})
+	if _, ok := bash.FilePath(); ok {
+		t.Error("Bash FilePath() ok = true, want false")
+	}
+}
@@ -0,0 +1,48 @@
+package claudecode
+
+import "github.com/musaprg/claude-code-sdk-go/internal/tracker"
+
+// Re-export message stream trackers from internal package.
+// Trackers observe the messages of a query and maintain derived state, such as the agent's todo list.
+type (
+	// TodoTracker maintains the agent's current todo list from TodoWrite tool calls.
+	TodoTracker = tracker.TodoTracker
+	// TodoChange describes an update of the todo list reported by a TodoWrite tool call.
+	TodoChange = tracker.TodoChange
+	// TodoProgress summarizes a todo list.
+	TodoProgress = tracker.TodoProgress
+
+	// FileChangeTracker records the files modified by Write, Edit, MultiEdit and NotebookEdit tool calls.
+	FileChangeTracker = tracker.FileChangeTracker
+	// FileChangeTrackerOptions configures a FileChangeTracker.
+	FileChangeTrackerOptions = tracker.FileChangeTrackerOptions
+	// FileChange describes a single file-modifying tool call and its outcome.
+	FileChange = tracker.FileChange
+	// FileOperation identifies the tool that modified a file.
+	FileOperation = tracker.FileOperation
+	// FileChangeStatus is the outcome of a file-modifying tool call.
+	FileChangeStatus = tracker.FileChangeStatus
+	// SnapshotFunc captures the content of a file.
+	SnapshotFunc = tracker.SnapshotFunc
+)
+
+// Re-export file change constants
+const (
+	FileOperationWrite        = tracker.FileOperationWrite
+	FileOperationEdit         = tracker.FileOperationEdit
+	FileOperationMultiEdit    = tracker.FileOperationMultiEdit
+	FileOperationNotebookEdit = tracker.FileOperationNotebookEdit
+
+	FileChangePending   = tracker.FileChangePending
+	FileChangeSucceeded = tracker.FileChangeSucceeded
+	FileChangeFailed    = tracker.FileChangeFailed
+)
+
+var (
+	// NewTodoTracker creates a TodoTracker that calls onChange for every change of the todo list.
+	NewTodoTracker = tracker.NewTodoTracker
+	// NewFileChangeTracker creates a FileChangeTracker.
+	NewFileChangeTracker = tracker.NewFileChangeTracker
+	// ReadFileSnapshot is a SnapshotFunc that reads the file from the local file system.
+	ReadFileSnapshot = tracker.ReadFileSnapshot
+)
@@ -0,0 +1,152 @@
+package claudecode
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func todoWriteMessage(id string, todos ...map[string]any) *AssistantMessage {
+	items := make([]any, len(todos))
+	for i, todo := range todos {
+		items[i] = todo
+	}
+	return NewAssistantMessage([]ContentBlock{
+		NewTextBlock("Updating the plan"),
+		NewToolUseBlock(id, ToolTodoWrite, map[string]any{"todos": items}),
+	})
+}
+
+func todo(content string, status TodoStatus) map[string]any {
+	return map[string]any{"content": content, "status": string(status), "activeForm": content + "..."}
+}
+
+func TestTodoTracker(t *testing.T) {
+	var changes []TodoChange
+	tracker := NewTodoTracker(func(change TodoChange) {
+		changes = append(changes, change)
+	})
+
+	messages := make(chan Message, 10)
+	messages <- NewSystemMessage("init", nil)
+	messages <- todoWriteMessage("t1",
+		todo("Write code", TodoStatusInProgress),
+		todo("Run tests", TodoStatusPending),
+		todo("Open PR", TodoStatusPending))
+	messages <- NewAssistantMessage([]ContentBlock{NewToolUseBlock("b1", ToolBash, map[string]any{"command": "go test"})})
+	messages <- todoWriteMessage("t2",
+		todo("Write code", TodoStatusCompleted),
+		todo("Run tests", TodoStatusInProgress),
+		todo("Open PR", TodoStatusPending))
+	// An identical list is not a change
+	messages <- todoWriteMessage("t3",
+		todo("Write code", TodoStatusCompleted),
+		todo("Run tests", TodoStatusInProgress),
+		todo("Open PR", TodoStatusPending))
+	close(messages)
+
+	var forwarded int
+	for range tracker.Track(messages) {
+		forwarded++
+	}
+
+	if forwarded != 5 {
+		t.Errorf("Track() forwarded %d messages, want 5", forwarded)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("onChange called %d times, want 2", len(changes))
+	}
+	if changes[0].ToolUseID != "t1" || len(changes[0].Previous) != 0 || len(changes[0].Current) != 3 {
+		t.Errorf("first change = %+v, want initial list from t1", changes[0])
+	}
+	if changes[1].ToolUseID != "t2" || changes[1].Previous[0].Status != TodoStatusInProgress {
+		t.Errorf("second change = %+v, want update from t2 with previous list", changes[1])
+	}
+
+	progress := tracker.Progress()
+	want := TodoProgress{Total: 3, Completed: 1, InProgress: 1, Pending: 1}
+	if progress != want {
+		t.Errorf("TodoTracker.Progress() = %+v, want %+v", progress, want)
+	}
+	if progress.String() != "1/3 tasks complete" {
+		t.Errorf("TodoProgress.String() = %q, want %q", progress.String(), "1/3 tasks complete")
+	}
+
+	current, ok := tracker.Current()
+	if !ok || current.Content != "Run tests" || current.ActiveForm != "Run tests..." {
+		t.Errorf("TodoTracker.Current() = %+v, %v; want Run tests", current, ok)
+	}
+
+	todos := tracker.Todos()
+	todos[0].Content = "modified"
+	if tracker.Todos()[0].Content != "Write code" {
+		t.Error("TodoTracker.Todos() should return a copy")
+	}
+}
+
+func toolResultMessage(toolUseID string, content any, isError bool) *UserMessage {
+	msg := NewUserMessage("")
+	msg.ContentBlocks = []ContentBlock{NewToolResultBlock(toolUseID, content, &isError)}
+	return msg
+}
+
+func TestFileChangeTracker(t *testing.T) {
+	dir := t.TempDir()
+	created := filepath.Join(dir, "new.go")
+	edited := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(edited, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var completed []FileChange
+	tracker := NewFileChangeTracker(&FileChangeTrackerOptions{
+		Snapshot: ReadFileSnapshot,
+		OnChange: func(change FileChange) { completed = append(completed, change) },
+	})
+
+	tracker.Observe(NewAssistantMessage([]ContentBlock{
+		NewToolUseBlock("w1", ToolWrite, map[string]any{"file_path": created, "content": "package main\n"}),
+		NewToolUseBlock("r1", ToolRead, map[string]any{"file_path": edited}),
+		NewToolUseBlock("e1", ToolEdit, map[string]any{"file_path": edited, "old_string": "main", "new_string": "app"}),
+		NewToolUseBlock("e2", ToolEdit, map[string]any{"file_path": edited, "old_string": "missing", "new_string": "x"}),
+	}))
+	// Simulate the CLI applying the changes before reporting the results
+	if err := os.WriteFile(created, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(edited, []byte("package app\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if changes := tracker.Changes(); len(changes) != 3 || changes[0].Status != FileChangePending {
+		t.Fatalf("Changes() before results = %+v, want 3 pending changes", changes)
+	}
+
+	tracker.Observe(toolResultMessage("w1", "File created successfully", false))
+	tracker.Observe(toolResultMessage("r1", "package main", false))
+	tracker.Observe(toolResultMessage("e1", "The file has been updated", false))
+	tracker.Observe(toolResultMessage("e2", []any{map[string]any{"type": "text", "text": "String not found"}}, true))
+
+	changes := tracker.Changes()
+	if len(changes) != 3 || len(completed) != 3 {
+		t.Fatalf("got %d changes and %d callbacks, want 3 each", len(changes), len(completed))
+	}
+
+	write := changes[0]
+	if write.Operation != FileOperationWrite || write.Status != FileChangeSucceeded || write.Before != nil || string(write.After) != "package main\n" {
+		t.Errorf("write change = %+v, want successful write of a new file", write)
+	}
+	edit := changes[1]
+	if edit.Operation != FileOperationEdit || string(edit.Before) != "package main\n" || string(edit.After) != "package app\n" {
+		t.Errorf("edit change = %+v, want before/after snapshots", edit)
+	}
+	failed := changes[2]
+	if failed.Status != FileChangeFailed || failed.Error != "String not found" || failed.After != nil {
+		t.Errorf("failed change = %+v, want failure with error text", failed)
+	}
+
+	paths := tracker.ChangedPaths()
+	if len(paths) != 2 || paths[0] != edited || paths[1] != created {
+		t.Errorf("ChangedPaths() = %v, want [%s %s]", paths, edited, created)
+	}
+}
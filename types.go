@@ -11,6 +11,10 @@ type (
 	ContentBlockType = types.ContentBlockType
 	// PermissionMode defines how tools are permitted to run during a Claude Code session.
 	PermissionMode = types.PermissionMode
+	// PromptDelivery controls how prompts are handed to the Claude Code CLI process.
+	PromptDelivery = types.PromptDelivery
+	// McpConfigDelivery controls how MCP server configurations are handed to the Claude Code CLI process.
+	McpConfigDelivery = types.McpConfigDelivery
 	// Message represents a message in the Claude Code conversation.
 	Message = types.Message
 	// ContentBlock represents a content block within a message.
@@ -23,14 +27,43 @@ type (
 	SystemMessage = types.SystemMessage
 	// ResultMessage represents the final result message containing conversation metadata.
 	ResultMessage = types.ResultMessage
+	// StreamEvent represents a partial message update emitted while a response is generated.
+	StreamEvent = types.StreamEvent
+	// ErrorMessage reports an SDK-side failure, such as an exceeded budget, that ended the stream early.
+	ErrorMessage = types.ErrorMessage
+	// Usage contains token counts reported by the API for a message.
+	Usage = types.Usage
 	// TextBlock represents a plain text content block within a message.
 	TextBlock = types.TextBlock
 	// ToolUseBlock represents a tool invocation by the assistant.
 	ToolUseBlock = types.ToolUseBlock
 	// ToolResultBlock represents the result of a tool execution.
 	ToolResultBlock = types.ToolResultBlock
-	// McpServerConfig represents configuration for a Model Context Protocol (MCP) server.
+	// ImageBlock represents an image supplied as part of a user prompt.
+	ImageBlock = types.ImageBlock
+	// DocumentBlock represents a PDF or plain text document supplied as part of a user prompt.
+	DocumentBlock = types.DocumentBlock
+	// ContentSource describes the payload of an image or document content block.
+	ContentSource = types.ContentSource
+	// Prompt composes a user message from text, image, and document content blocks.
+	Prompt = types.Prompt
+	// McpServerConfig represents configuration for a Model Context Protocol (MCP) server:
+	// one of McpStdioServer, McpSSEServer, McpHTTPServer, or McpSDKServer.
 	McpServerConfig = types.McpServerConfig
+	// McpServerType identifies how Claude Code connects to an MCP server.
+	McpServerType = types.McpServerType
+	// McpStdioServer is an MCP server process started by the CLI.
+	McpStdioServer = types.McpStdioServer
+	// McpSSEServer is a remote MCP server reached over HTTP with server-sent events.
+	McpSSEServer = types.McpSSEServer
+	// McpHTTPServer is a remote MCP server reached over streamable HTTP.
+	McpHTTPServer = types.McpHTTPServer
+	// McpSDKServer is an MCP server that runs in the process of an SDK. It is not supported by this SDK yet.
+	McpSDKServer = types.McpSDKServer
+	// AgentDefinition describes a custom subagent that Claude can delegate tasks to.
+	AgentDefinition = types.AgentDefinition
+	// SettingSource identifies a Claude Code settings file location.
+	SettingSource = types.SettingSource
 	// QueryOptions contains configuration options for Claude Code queries.
 	QueryOptions = types.QueryOptions
 	// ClientOptions contains configuration options for creating a new Claude Code SDK client.
@@ -38,7 +71,7 @@ type (
 )
 
 // Re-export constants from internal package.
-// These constants define the available message types, content block types, and permission modes.
+// These constants define the available message types, content block types, permission modes, and prompt delivery modes.
 const (
 	// MessageTypeUser represents a message from the human user.
 	MessageTypeUser = types.MessageTypeUser
@@ -48,6 +81,10 @@ const (
 	MessageTypeSystem = types.MessageTypeSystem
 	// MessageTypeResult represents final result messages with conversation metadata.
 	MessageTypeResult = types.MessageTypeResult
+	// MessageTypeStreamEvent represents partial message updates emitted while a response is generated.
+	MessageTypeStreamEvent = types.MessageTypeStreamEvent
+	// MessageTypeError represents an SDK-side failure that ended the message stream early.
+	MessageTypeError = types.MessageTypeError
 
 	// ContentBlockTypeText represents plain text content.
 	ContentBlockTypeText = types.ContentBlockTypeText
@@ -55,6 +92,10 @@ const (
 	ContentBlockTypeToolUse = types.ContentBlockTypeToolUse
 	// ContentBlockTypeToolResult represents the result of a tool execution.
 	ContentBlockTypeToolResult = types.ContentBlockTypeToolResult
+	// ContentBlockTypeImage represents an image supplied in a user prompt.
+	ContentBlockTypeImage = types.ContentBlockTypeImage
+	// ContentBlockTypeDocument represents a PDF or text document supplied in a user prompt.
+	ContentBlockTypeDocument = types.ContentBlockTypeDocument
 
 	// PermissionModeDefault uses the standard permission prompts for tool usage.
 	PermissionModeDefault = types.PermissionModeDefault
@@ -62,6 +103,52 @@ const (
 	PermissionModeAcceptEdits = types.PermissionModeAcceptEdits
 	// PermissionModeBypassPermissions bypasses all permission checks (recommended only for sandboxes).
 	PermissionModeBypassPermissions = types.PermissionModeBypassPermissions
+	// PermissionModePlan lets Claude analyze and plan without executing tools that modify anything.
+	PermissionModePlan = types.PermissionModePlan
+
+	// SettingSourceUser loads global user settings (~/.claude/settings.json).
+	SettingSourceUser = types.SettingSourceUser
+	// SettingSourceProject loads shared project settings (.claude/settings.json).
+	SettingSourceProject = types.SettingSourceProject
+	// SettingSourceLocal loads local project settings (.claude/settings.local.json).
+	SettingSourceLocal = types.SettingSourceLocal
+
+	// PromptDeliveryAuto passes short prompts via argv and switches to stdin for large prompts.
+	PromptDeliveryAuto = types.PromptDeliveryAuto
+	// PromptDeliveryArgv always passes the prompt as a command-line argument.
+	PromptDeliveryArgv = types.PromptDeliveryArgv
+	// PromptDeliveryStdin always streams the prompt over stdin and keeps system prompts out of argv.
+	PromptDeliveryStdin = types.PromptDeliveryStdin
+
+	// McpServerTypeStdio servers are processes started by the CLI.
+	McpServerTypeStdio = types.McpServerTypeStdio
+	// McpServerTypeSSE servers are remote servers reached over HTTP with server-sent events.
+	McpServerTypeSSE = types.McpServerTypeSSE
+	// McpServerTypeHTTP servers are remote servers reached over streamable HTTP.
+	McpServerTypeHTTP = types.McpServerTypeHTTP
+	// McpServerTypeSDK servers run in the process of an SDK.
+	McpServerTypeSDK = types.McpServerTypeSDK
+
+	// McpConfigDeliveryAuto writes MCP configurations with environment variables or headers to
+	// a temporary file and passes other configurations via argv.
+	McpConfigDeliveryAuto = types.McpConfigDeliveryAuto
+	// McpConfigDeliveryArgv always passes the MCP configuration as a command-line argument.
+	McpConfigDeliveryArgv = types.McpConfigDeliveryArgv
+	// McpConfigDeliveryFile always passes the MCP configuration in a private temporary file.
+	McpConfigDeliveryFile = types.McpConfigDeliveryFile
+
+	// MediaTypeJPEG is the media type of JPEG images.
+	MediaTypeJPEG = types.MediaTypeJPEG
+	// MediaTypePNG is the media type of PNG images.
+	MediaTypePNG = types.MediaTypePNG
+	// MediaTypeGIF is the media type of GIF images.
+	MediaTypeGIF = types.MediaTypeGIF
+	// MediaTypeWebP is the media type of WebP images.
+	MediaTypeWebP = types.MediaTypeWebP
+	// MediaTypePDF is the media type of PDF documents.
+	MediaTypePDF = types.MediaTypePDF
+	// MediaTypeText is the media type of plain text documents.
+	MediaTypeText = types.MediaTypeText
 )
 
 // Re-export constructor functions from internal package.
@@ -75,10 +162,16 @@ var (
 	NewSystemMessage = types.NewSystemMessage
 	// NewResultMessage creates a new ResultMessage with the given parameters.
 	NewResultMessage = types.NewResultMessage
+	// NewStreamEvent creates a new StreamEvent with the given identifiers and raw event.
+	NewStreamEvent = types.NewStreamEvent
+	// NewErrorMessage creates a new ErrorMessage wrapping err.
+	NewErrorMessage = types.NewErrorMessage
 	// NewTextBlock creates a new TextBlock with the given text content.
 	NewTextBlock = types.NewTextBlock
 	// NewToolUseBlock creates a new ToolUseBlock with the given parameters.
 	NewToolUseBlock = types.NewToolUseBlock
 	// NewToolResultBlock creates a new ToolResultBlock with the given parameters.
 	NewToolResultBlock = types.NewToolResultBlock
+	// NewPrompt creates an empty Prompt to which text, images, and documents can be appended.
+	NewPrompt = types.NewPrompt
 )
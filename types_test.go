@@ -1,6 +1,13 @@
 package claudecode
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -226,8 +233,7 @@ func TestQueryOptions(t *testing.T) {
 		CWD:            "/tmp",
 		PermissionMode: PermissionModeDefault,
 		McpServers: map[string]McpServerConfig{
-			"server1": {
-				Type:    "stdio",
+			"server1": McpStdioServer{
 				Command: "mcp-server",
 				Args:    []string{"--port", "8080"},
 				Env:     map[string]string{"ENV": "prod"},
@@ -281,3 +287,188 @@ func TestClientOptions(t *testing.T) {
 		}
 	})
 }
+
+func TestPrompt(t *testing.T) {
+	t.Run("text only", func(t *testing.T) {
+		prompt := NewPrompt().Text("first").Text("second")
+		if err := prompt.Err(); err != nil {
+			t.Fatalf("Prompt.Err() = %v, want nil", err)
+		}
+		text, ok := prompt.PlainText()
+		if !ok {
+			t.Fatal("Prompt.PlainText() ok = false, want true")
+		}
+		if text != "first\n\nsecond" {
+			t.Errorf("Prompt.PlainText() = %q, want %q", text, "first\n\nsecond")
+		}
+	})
+
+	t.Run("multimodal", func(t *testing.T) {
+		dir := t.TempDir()
+		imagePath := filepath.Join(dir, "screenshot.png")
+		if err := os.WriteFile(imagePath, []byte("png-bytes"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		logPath := filepath.Join(dir, "test.log")
+		if err := os.WriteFile(logPath, []byte("FAIL: TestLogin"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		prompt := NewPrompt().
+			Text("Why does this test fail?").
+			ImageFile(imagePath).
+			DocumentFile(logPath).
+			DocumentBytes([]byte("%PDF"), MediaTypePDF)
+		if err := prompt.Err(); err != nil {
+			t.Fatalf("Prompt.Err() = %v, want nil", err)
+		}
+		if _, ok := prompt.PlainText(); ok {
+			t.Error("Prompt.PlainText() ok = true, want false for multimodal prompt")
+		}
+
+		data, err := json.Marshal(prompt)
+		if err != nil {
+			t.Fatalf("json.Marshal(Prompt) error = %v", err)
+		}
+		var content []map[string]any
+		if err := json.Unmarshal(data, &content); err != nil {
+			t.Fatal(err)
+		}
+		if len(content) != 4 {
+			t.Fatalf("content length = %d, want 4", len(content))
+		}
+
+		wantTypes := []string{"text", "image", "document", "document"}
+		for i, want := range wantTypes {
+			if content[i]["type"] != want {
+				t.Errorf("content[%d].type = %v, want %v", i, content[i]["type"], want)
+			}
+		}
+
+		image := content[1]["source"].(map[string]any)
+		if image["media_type"] != MediaTypePNG || image["type"] != "base64" {
+			t.Errorf("image source = %v, want base64 image/png", image)
+		}
+		if image["data"] != base64.StdEncoding.EncodeToString([]byte("png-bytes")) {
+			t.Errorf("image data = %v, want base64 of file contents", image["data"])
+		}
+
+		logDoc := content[2]["source"].(map[string]any)
+		if logDoc["type"] != "text" || logDoc["data"] != "FAIL: TestLogin" {
+			t.Errorf("text document source = %v, want plain text contents", logDoc)
+		}
+
+		pdfDoc := content[3]["source"].(map[string]any)
+		if pdfDoc["type"] != "base64" || pdfDoc["media_type"] != MediaTypePDF {
+			t.Errorf("pdf document source = %v, want base64 application/pdf", pdfDoc)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			prompt *Prompt
+		}{
+			{name: "nil"},
+			{name: "empty", prompt: NewPrompt()},
+			{name: "missing image file", prompt: NewPrompt().ImageFile(filepath.Join(t.TempDir(), "missing.png"))},
+			{name: "unsupported image extension", prompt: NewPrompt().ImageFile("diagram.svg")},
+			{name: "unsupported image media type", prompt: NewPrompt().ImageBytes([]byte("x"), "image/tiff")},
+			{name: "unsupported document media type", prompt: NewPrompt().DocumentBase64("eA==", "application/zip")},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if err := tt.prompt.Err(); err == nil {
+					t.Error("Prompt.Err() = nil, want error")
+				}
+			})
+		}
+	})
+}
+
+func TestQueryOptionsValidate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		var nilOptions *QueryOptions
+		if err := nilOptions.Validate(); err != nil {
+			t.Errorf("(*QueryOptions)(nil).Validate() = %v, want nil", err)
+		}
+		options := &QueryOptions{
+			AllowedTools:    []string{"Read"},
+			DisallowedTools: []string{"Bash"},
+			PermissionMode:  PermissionModePlan,
+			McpServers: map[string]McpServerConfig{
+				"local":  McpStdioServer{Command: "mcp-local"},
+				"remote": McpHTTPServer{URL: "https://mcp.example.com"},
+				"events": McpSSEServer{URL: "http://localhost:8080/sse"},
+			},
+		}
+		if err := options.Validate(); err != nil {
+			t.Errorf("QueryOptions.Validate() = %v, want nil", err)
+		}
+	})
+
+	t.Run("aggregated errors", func(t *testing.T) {
+		options := &QueryOptions{
+			ContinueConversation: true,
+			Resume:               "session-1",
+			AllowedTools:         []string{"Read", "Bash"},
+			DisallowedTools:      []string{"Bash"},
+			PermissionMode:       "yolo",
+			MaxTurns:             -1,
+			SettingSources:       []SettingSource{"global"},
+			McpServers: map[string]McpServerConfig{
+				"local":  McpStdioServer{},
+				"remote": McpHTTPServer{},
+				"events": McpSSEServer{URL: "mcp.example.com/sse"},
+				"sdk":    McpSDKServer{Name: "tools"},
+				"ptr":    &McpStdioServer{Command: "mcp-local"},
+				"nil":    nil,
+			},
+			Agents: map[string]AgentDefinition{"reviewer": {Prompt: "Review code."}},
+		}
+
+		err := options.Validate()
+		if err == nil {
+			t.Fatal("QueryOptions.Validate() = nil, want error")
+		}
+
+		wantFields := []string{
+			"QueryOptions.Resume",
+			"QueryOptions.DisallowedTools",
+			"QueryOptions.PermissionMode",
+			"QueryOptions.MaxTurns",
+			"QueryOptions.SettingSources[0]",
+			`QueryOptions.McpServers["events"].URL`,
+			`QueryOptions.McpServers["local"].Command`,
+			`QueryOptions.McpServers["nil"]`,
+			`QueryOptions.McpServers["ptr"]`,
+			`QueryOptions.McpServers["remote"].URL`,
+			`QueryOptions.McpServers["sdk"]`,
+			`QueryOptions.Agents["reviewer"].Description`,
+		}
+		joined, ok := err.(interface{ Unwrap() []error })
+		if !ok {
+			t.Fatalf("QueryOptions.Validate() error %T does not wrap multiple errors", err)
+		}
+		var gotFields []string
+		for _, e := range joined.Unwrap() {
+			var validationErr *ValidationError
+			if !errors.As(e, &validationErr) {
+				t.Fatalf("error %v is not a *ValidationError", e)
+			}
+			gotFields = append(gotFields, validationErr.Field)
+		}
+		if !slices.Equal(gotFields, wantFields) {
+			t.Errorf("validation error fields = %q, want %q", gotFields, wantFields)
+		}
+	})
+
+	t.Run("checked by Query", func(t *testing.T) {
+		client := NewClient(&ClientOptions{CLIPath: filepath.Join(t.TempDir(), "missing")})
+		_, err := client.Query(context.Background(), "hi", &QueryOptions{ContinueConversation: true, Resume: "s"})
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Client.Query() error = %v, want *ValidationError", err)
+		}
+	})
+}
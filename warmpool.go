@@ -0,0 +1,292 @@
+package claudecode
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/errors"
+	"github.com/musaprg/claude-code-sdk-go/internal/transport"
+)
+
+// Default warm pool settings
+const (
+	DefaultWarmPoolSize                = 2
+	DefaultWarmPoolMaxAge              = 10 * time.Minute
+	DefaultWarmPoolHealthCheckInterval = 10 * time.Second
+)
+
+// WarmPoolOptions configures a WarmPool.
+type WarmPoolOptions struct {
+	// Size is the number of idle CLI processes kept ready. Defaults to DefaultWarmPoolSize.
+	Size int
+	// MaxAge is how long an idle process is kept before it is replaced. Defaults to DefaultWarmPoolMaxAge.
+	MaxAge time.Duration
+	// HealthCheckInterval is how often idle processes are checked and the pool is refilled.
+	// Defaults to DefaultWarmPoolHealthCheckInterval.
+	HealthCheckInterval time.Duration
+}
+
+// warmProcess is an idle CLI process waiting for its prompt.
+type warmProcess struct {
+	transport *transport.SubprocessTransport
+	messageCh <-chan Message
+	spawned   time.Time
+}
+
+// WarmPool keeps CLI processes started ahead of time so that queries do not wait for the
+// CLI to start up. All processes share the base QueryOptions given when the pool is created;
+// only the prompt differs per query. Each process serves a single query and is replaced afterwards.
+// It is safe for concurrent use.
+type WarmPool struct {
+	client  *Client
+	options *QueryOptions
+	config  WarmPoolOptions
+
+	// stop is closed by Close to end the maintenance loop.
+	stop chan struct{}
+	// refill wakes the maintenance loop after a process was handed out.
+	refill chan struct{}
+	done   chan struct{}
+
+	mu     sync.Mutex
+	idle   []*warmProcess
+	closed bool
+}
+
+// NewWarmPool creates a WarmPool that runs queries with client and options, merged on top of
+// the client's query defaults. It starts filling the pool in the background.
+// poolOptions may be nil.
+func NewWarmPool(client *Client, options *QueryOptions, poolOptions *WarmPoolOptions) (*WarmPool, error) {
+	options = client.defaults.Merge(options)
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	config := WarmPoolOptions{}
+	if poolOptions != nil {
+		config = *poolOptions
+	}
+	if config.Size <= 0 {
+		config.Size = DefaultWarmPoolSize
+	}
+	if config.MaxAge <= 0 {
+		config.MaxAge = DefaultWarmPoolMaxAge
+	}
+	if config.HealthCheckInterval <= 0 {
+		config.HealthCheckInterval = DefaultWarmPoolHealthCheckInterval
+	}
+
+	p := &WarmPool{
+		client:  client,
+		options: options,
+		config:  config,
+		stop:    make(chan struct{}),
+		refill:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go p.maintain()
+	return p, nil
+}
+
+// Query runs a query on a warm CLI process, like Client.Query with the pool's options.
+func (p *WarmPool) Query(ctx context.Context, prompt string) (<-chan Message, error) {
+	return p.QueryPrompt(ctx, NewPrompt().Text(prompt))
+}
+
+// QueryPrompt runs a multimodal query on a warm CLI process, like Client.QueryPrompt with the
+// pool's options. If no healthy idle process is available, a new one is started for the query.
+func (p *WarmPool) QueryPrompt(ctx context.Context, prompt *Prompt) (<-chan Message, error) {
+	if err := prompt.Err(); err != nil {
+		return nil, err
+	}
+
+	observer := p.client.startObserver(ctx, p.options)
+	messageCh, err := p.start(ctx, prompt)
+	if err != nil {
+		endObserver(observer, err)
+		return nil, err
+	}
+	if p.options.Retry != nil {
+		// Retries start new processes rather than taking more from the pool
+		messageCh = p.client.retry(ctx, prompt, p.options, messageCh)
+	}
+	return observe(ctx, observer, messageCh), nil
+}
+
+// start sends the prompt to a warm process, or to a new one if none is available.
+func (p *WarmPool) start(ctx context.Context, prompt *Prompt) (<-chan Message, error) {
+	limits, reservation, err := queryLimits(p.options)
+	if err != nil {
+		return nil, err
+	}
+
+	// The process is already running, but the API is not called until the prompt is sent
+	gate, err := p.client.reserve(ctx, p.options)
+	if err != nil {
+		reservation.Release()
+		return nil, err
+	}
+
+	process, err := p.take()
+	if err == nil && process == nil {
+		// The pool is drained; start a process for this query only
+		process, err = p.spawn(ctx)
+	}
+	if err != nil {
+		reservation.Release()
+		gate.cancel()
+		return nil, err
+	}
+
+	if err := process.transport.Send(prompt); err != nil {
+		process.transport.Close()
+		reservation.Release()
+		gate.cancel()
+		return nil, err
+	}
+	return stream(ctx, process.transport, process.messageCh, limits, reservation, gate), nil
+}
+
+// Idle returns the number of idle processes currently in the pool.
+func (p *WarmPool) Idle() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.idle)
+}
+
+// Close stops all idle processes. Queries already running are not affected.
+func (p *WarmPool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		<-p.done
+		return nil
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	close(p.stop)
+	<-p.done
+	for _, process := range idle {
+		process.transport.Close()
+	}
+	return nil
+}
+
+// take removes and returns a healthy idle process, or nil if there is none.
+func (p *WarmPool) take() (*warmProcess, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, errors.NewCLIConnectionError("warm pool is closed", nil)
+	}
+
+	for len(p.idle) > 0 {
+		process := p.idle[0]
+		p.idle = p.idle[1:]
+		if p.healthy(process) {
+			p.requestRefill()
+			return process, nil
+		}
+		go process.transport.Close()
+	}
+	p.requestRefill()
+	return nil, nil
+}
+
+// spawn starts a CLI process that waits for its prompt.
+func (p *WarmPool) spawn(ctx context.Context) (*warmProcess, error) {
+	t := p.client.newTransport()
+	if err := t.Spawn(ctx, p.options); err != nil {
+		return nil, err
+	}
+	// Reading output right away lets the transport notice if the process dies while idle
+	messageCh, err := t.ReceiveMessages(ctx)
+	if err != nil {
+		t.Close()
+		return nil, err
+	}
+	return &warmProcess{transport: t, messageCh: messageCh, spawned: time.Now()}, nil
+}
+
+// healthy reports whether an idle process can still serve a query. The caller must hold p.mu.
+func (p *WarmPool) healthy(process *warmProcess) bool {
+	return !process.transport.Exited() && time.Since(process.spawned) < p.config.MaxAge
+}
+
+// requestRefill wakes the maintenance loop without blocking.
+func (p *WarmPool) requestRefill() {
+	select {
+	case p.refill <- struct{}{}:
+	default:
+	}
+}
+
+// maintain evicts unhealthy idle processes and keeps the pool filled until the pool is closed.
+func (p *WarmPool) maintain() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.config.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		p.evict()
+		p.fill()
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		case <-p.refill:
+		}
+	}
+}
+
+// evict stops idle processes that have exited or exceeded their maximum age.
+func (p *WarmPool) evict() {
+	p.mu.Lock()
+	var evicted []*warmProcess
+	kept := p.idle[:0]
+	for _, process := range p.idle {
+		if p.healthy(process) {
+			kept = append(kept, process)
+		} else {
+			evicted = append(evicted, process)
+		}
+	}
+	p.idle = kept
+	p.mu.Unlock()
+
+	for _, process := range evicted {
+		process.transport.Close()
+	}
+}
+
+// fill starts processes until the pool holds Size idle processes. Spawn failures are
+// retried on the next health check.
+func (p *WarmPool) fill() {
+	for {
+		p.mu.Lock()
+		missing := !p.closed && len(p.idle) < p.config.Size
+		p.mu.Unlock()
+		if !missing {
+			return
+		}
+
+		// Idle processes are not tied to any query's context; they are stopped by evict or Close
+		process, err := p.spawn(context.Background())
+		if err != nil {
+			return
+		}
+
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			process.transport.Close()
+			return
+		}
+		p.idle = append(p.idle, process)
+		p.mu.Unlock()
+	}
+}
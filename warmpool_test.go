@@ -0,0 +1,163 @@
+package claudecode
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeWarmCLI writes a fake CLI that records each start in dir/spawns and answers
+// once its prompt arrives on stdin.
+func writeWarmCLI(t *testing.T, dir string) string {
+	t.Helper()
+	path := filepath.Join(dir, "fake-claude")
+	script := `#!/bin/sh
+if [ "$1" = "--version" ]; then echo "2.0.0 (Claude Code)"; exit 0; fi
+echo spawn >> "` + dir + `/spawns"
+read -r line || exit 0
+echo '{"type":"assistant","message":{"content":[{"type":"text","text":"warm"}]}}'
+echo '{"type":"result","subtype":"success","duration_ms":10,"duration_api_ms":5,"is_error":false,"num_turns":1,"session_id":"s1"}'
+`
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func countSpawns(t *testing.T, dir string) int {
+	t.Helper()
+	data, _ := os.ReadFile(filepath.Join(dir, "spawns"))
+	return strings.Count(string(data), "spawn")
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWarmPool(t *testing.T) {
+	dir := t.TempDir()
+	client := NewClient(WithCLIPath(writeWarmCLI(t, dir)), WithSkipVersionCheck())
+
+	pool, err := NewWarmPool(client, nil, &WarmPoolOptions{Size: 2})
+	if err != nil {
+		t.Fatalf("NewWarmPool() error = %v", err)
+	}
+	defer pool.Close()
+	waitFor(t, "the pool to fill", func() bool { return pool.Idle() == 2 })
+
+	messages, err := pool.Query(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	var received []Message
+	for message := range messages {
+		received = append(received, message)
+	}
+	if len(received) != 2 {
+		t.Fatalf("got %d messages, want 2", len(received))
+	}
+	if _, ok := received[1].(*ResultMessage); !ok {
+		t.Errorf("last message = %T, want *ResultMessage", received[1])
+	}
+
+	// The used process is replaced
+	waitFor(t, "the pool to refill", func() bool { return pool.Idle() == 2 && countSpawns(t, dir) == 3 })
+}
+
+func TestQueryPromptNil(t *testing.T) {
+	dir := t.TempDir()
+	client := NewClient(WithCLIPath(writeWarmCLI(t, dir)), WithSkipVersionCheck())
+	if _, err := client.QueryPrompt(context.Background(), nil, nil); err == nil {
+		t.Error("Client.QueryPrompt(nil) error = nil, want error")
+	}
+
+	pool, err := NewWarmPool(client, nil, &WarmPoolOptions{Size: 1})
+	if err != nil {
+		t.Fatalf("NewWarmPool() error = %v", err)
+	}
+	defer pool.Close()
+	if _, err := pool.QueryPrompt(context.Background(), nil); err == nil {
+		t.Error("WarmPool.QueryPrompt(nil) error = nil, want error")
+	}
+	if countSpawns(t, dir) > 1 {
+		t.Error("a nil prompt started a CLI process")
+	}
+}
+
+func TestWarmPoolMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	client := NewClient(WithCLIPath(writeWarmCLI(t, dir)), WithSkipVersionCheck())
+
+	pool, err := NewWarmPool(client, nil, &WarmPoolOptions{
+		Size:                1,
+		MaxAge:              50 * time.Millisecond,
+		HealthCheckInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewWarmPool() error = %v", err)
+	}
+
+	waitFor(t, "an expired process to be replaced", func() bool { return countSpawns(t, dir) >= 3 })
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if pool.Idle() != 0 {
+		t.Errorf("Idle() = %d after Close, want 0", pool.Idle())
+	}
+	if _, err := pool.Query(context.Background(), "hello"); err == nil {
+		t.Error("Query() on a closed pool should fail")
+	}
+}
+
+func TestWarmPoolCancelledQuery(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fake-claude")
+	script := `#!/bin/sh
+if [ "$1" = "--version" ]; then echo "2.0.0 (Claude Code)"; exit 0; fi
+read -r line || exit 0
+i=0
+while [ $i -lt 50 ]; do
+  echo '{"type":"assistant","message":{"content":[{"type":"text","text":"chunk"}]}}'
+  i=$((i+1))
+done
+exec sleep 30
+`
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	goroutines := runtime.NumGoroutine()
+
+	client := NewClient(WithCLIPath(path), WithSkipVersionCheck())
+	pool, err := NewWarmPool(client, nil, &WarmPoolOptions{Size: 1})
+	if err != nil {
+		t.Fatalf("NewWarmPool() error = %v", err)
+	}
+	waitFor(t, "the pool to fill", func() bool { return pool.Idle() == 1 })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	messages, err := pool.Query(ctx, "hello")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	// Stop reading mid-stream, while the CLI output fills the transport's buffer
+	<-messages
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	for range messages {
+	}
+	pool.Close()
+
+	waitFor(t, "the query's goroutines to exit", func() bool { return runtime.NumGoroutine() <= goroutines })
+}
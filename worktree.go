@@ -0,0 +1,118 @@
+package claudecode
+
+import (
+	"context"
+	"errors"
+
+	"github.com/musaprg/claude-code-sdk-go/internal/worktree"
+)
+
+// DefaultWorktreeBranchPrefix is the prefix of the branches created by a WorktreeRunner.
+const DefaultWorktreeBranchPrefix = "claude/"
+
+// WorktreeOptions configures a WorktreeRunner.
+type WorktreeOptions struct {
+	// Base is the revision each worktree is created from. Defaults to the repository's HEAD.
+	Base string
+	// BranchPrefix is prepended to the random name of each worktree branch.
+	// Defaults to DefaultWorktreeBranchPrefix.
+	BranchPrefix string
+	// KeepBranch keeps the worktree branch after the worktree is removed. The agent's
+	// changes are committed to the branch with CommitMessage, which must then be set.
+	KeepBranch bool
+	// CommitMessage is the message of the commit recording the agent's changes when KeepBranch is set.
+	CommitMessage string
+}
+
+// WorktreeResult is the outcome of a query run in an isolated worktree.
+type WorktreeResult struct {
+	// Messages contains every message of the query.
+	Messages []Message
+	// Result is the final ResultMessage of the query, or nil if the query ended without one.
+	Result *ResultMessage
+	// Base is the commit the worktree was created from.
+	Base string
+	// Branch is the worktree branch. It is only kept after Run returns if KeepBranch was set
+	// and Run succeeded.
+	Branch string
+	// Commit is the head of Branch after the agent's changes were committed.
+	// It is empty unless KeepBranch was set.
+	Commit string
+	// Diff is the agent's changes as a binary git patch against Base, suitable for git apply.
+	Diff string
+}
+
+// WorktreeRunner runs each query in a fresh git worktree of a repository, so that
+// concurrent queries against the same repository do not interfere with each other.
+// It is safe for concurrent use.
+type WorktreeRunner struct {
+	client  *Client
+	repo    string
+	options WorktreeOptions
+}
+
+// NewWorktreeRunner creates a WorktreeRunner that runs queries with client in worktrees of repo,
+// which may be any directory inside a git repository. options may be nil.
+func NewWorktreeRunner(client *Client, repo string, options *WorktreeOptions) *WorktreeRunner {
+	r := &WorktreeRunner{client: client, repo: repo}
+	if options != nil {
+		r.options = *options
+	}
+	if r.options.BranchPrefix == "" {
+		r.options.BranchPrefix = DefaultWorktreeBranchPrefix
+	}
+	return r
+}
+
+// Run creates a worktree, runs the query to completion with the worktree as its working
+// directory, and collects the resulting diff before removing the worktree.
+// QueryOptions.CWD is overridden. The worktree is removed even if the query fails.
+func (r *WorktreeRunner) Run(ctx context.Context, prompt *Prompt, options *QueryOptions) (result *WorktreeResult, err error) {
+	if r.options.KeepBranch && r.options.CommitMessage == "" {
+		return nil, NewValidationError("WorktreeOptions.CommitMessage", "must be set when KeepBranch is set")
+	}
+
+	wt, err := worktree.Add(ctx, r.repo, r.options.Base, r.options.BranchPrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		// Clean up even if the query was cancelled
+		if removeErr := wt.Remove(context.WithoutCancel(ctx), r.options.KeepBranch && err == nil); removeErr != nil {
+			err = errors.Join(err, removeErr)
+		}
+	}()
+
+	queryOptions := &QueryOptions{}
+	if options != nil {
+		copied := *options
+		queryOptions = &copied
+	}
+	queryOptions.CWD = wt.Path
+
+	messageCh, err := r.client.QueryPrompt(ctx, prompt, queryOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	result = &WorktreeResult{Base: wt.Base, Branch: wt.Branch}
+	for message := range messageCh {
+		result.Messages = append(result.Messages, message)
+		if msg, ok := message.(*ResultMessage); ok {
+			result.Result = msg
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	if result.Diff, err = wt.Diff(ctx); err != nil {
+		return result, err
+	}
+	if r.options.KeepBranch {
+		if result.Commit, err = wt.Commit(ctx, r.options.CommitMessage); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,109 @@
+package claudecode
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestWorktreeRunner(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "test")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "test@example.com")
+	}
+
+	repo := t.TempDir()
+	gitCmd(t, repo, "init", "-q", "-b", "main")
+	if err := os.WriteFile(filepath.Join(repo, "file.txt"), []byte("original\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitCmd(t, repo, "add", "-A")
+	gitCmd(t, repo, "commit", "-q", "-m", "initial")
+
+	client := NewClient(WithCLIPath(writeEditingCLI(t, false)))
+	ctx := context.Background()
+
+	t.Run("concurrent runs are isolated", func(t *testing.T) {
+		runner := NewWorktreeRunner(client, repo, nil)
+
+		const runs = 4
+		results := make([]*WorktreeResult, runs)
+		errs := make([]error, runs)
+		var wg sync.WaitGroup
+		for i := range runs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], errs[i] = runner.Run(ctx, NewPrompt().Text("edit"), nil)
+			}()
+		}
+		wg.Wait()
+
+		branches := make(map[string]bool)
+		for i := range runs {
+			if errs[i] != nil {
+				t.Fatalf("Run() error = %v", errs[i])
+			}
+			result := results[i]
+			if result.Result == nil || len(result.Messages) != 1 {
+				t.Errorf("Run() result = %+v, want the result message", result)
+			}
+			if !strings.Contains(result.Diff, "-original") || !strings.Contains(result.Diff, "+modified") {
+				t.Errorf("Run() diff = %q, want file.txt change", result.Diff)
+			}
+			if !strings.HasPrefix(result.Branch, DefaultWorktreeBranchPrefix) || branches[result.Branch] {
+				t.Errorf("Run() branch = %q, want a unique claude/ branch", result.Branch)
+			}
+			branches[result.Branch] = true
+		}
+
+		if got := gitCmd(t, repo, "status", "--porcelain"); got != "" {
+			t.Errorf("repository status = %q, want clean", got)
+		}
+		if got := gitCmd(t, repo, "branch", "--list", "claude/*"); got != "" {
+			t.Errorf("leftover branches = %q, want none", got)
+		}
+		if got := gitCmd(t, repo, "worktree", "list", "--porcelain"); strings.Count(got, "worktree ") != 1 {
+			t.Errorf("leftover worktrees:\n%s", got)
+		}
+	})
+
+	t.Run("keep branch", func(t *testing.T) {
+		runner := NewWorktreeRunner(client, repo, &WorktreeOptions{KeepBranch: true, CommitMessage: "Agent changes"})
+		result, err := runner.Run(ctx, NewPrompt().Text("edit"), nil)
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if got := gitCmd(t, repo, "rev-parse", result.Branch); got != result.Commit {
+			t.Errorf("branch %s = %s, want %s", result.Branch, got, result.Commit)
+		}
+		if got := gitCmd(t, repo, "show", result.Commit+":file.txt"); got != "modified" {
+			t.Errorf("committed file.txt = %q, want modified", got)
+		}
+	})
+
+	t.Run("keep branch requires commit message", func(t *testing.T) {
+		runner := NewWorktreeRunner(client, repo, &WorktreeOptions{KeepBranch: true})
+		if _, err := runner.Run(ctx, NewPrompt().Text("edit"), nil); err == nil {
+			t.Error("Run() should fail without a commit message")
+		}
+	})
+}